@@ -17,8 +17,14 @@ limitations under the License.
 package client
 
 import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	"k8s.io/client-go/kubernetes"
@@ -37,13 +43,86 @@ const (
 	DefaultTimeoutSeconds         = 10
 )
 
+var scheme = runtime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(clusterv1alpha2.AddToScheme(scheme))
+}
+
+// Options are the options for creating a client with New.
+type Options struct {
+	// Cluster limits the client to a single cluster, all clusters are
+	// searched when it is empty.
+	Cluster string
+
+	// Proxy sends the requests through the cluster's proxy path to the
+	// member cluster instead of reading from clusterpedia, Cluster is required.
+	Proxy bool
+
+	// BasePath is the path clusterpedia is served under when it sits
+	// behind a gateway or an ingress sub-path.
+	BasePath string
+
+	// Scheme maps go structs to GroupVersionKinds, defaults to the client-go
+	// types and cluster.clusterpedia.io/v1alpha2.
+	Scheme *runtime.Scheme
+
+	// Mapper maps GroupVersionKinds to Resources, defaults to a dynamic
+	// mapper discovering the resources through clusterpedia.
+	Mapper meta.RESTMapper
+
+	// HTTPClient is the HTTP client used for requests, it is built from
+	// the config when not provided.
+	HTTPClient *http.Client
+
+	// QPS, Burst and Timeout override the values of the config, DefaultQPS,
+	// DefaultBurst and DefaultTimeoutSeconds are used when neither sets them.
+	QPS     float32
+	Burst   int
+	Timeout time.Duration
+}
+
+// New returns a client.Client reading from clusterpedia with the given options.
+func New(cfg *rest.Config, opts Options) (client.Client, error) {
+	if opts.Proxy && opts.Cluster == "" {
+		return nil, errors.New("cluster is required for proxy client")
+	}
+
+	restConfig := *cfg
+	if opts.QPS != 0 {
+		restConfig.QPS = opts.QPS
+	}
+	if opts.Burst != 0 {
+		restConfig.Burst = opts.Burst
+	}
+	if opts.Timeout != 0 {
+		restConfig.Timeout = opts.Timeout
+	}
+
+	config, err := configFor(&restConfig, opts.BasePath, opts.Cluster, opts.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	clientOptions := client.Options{
+		Scheme:     opts.Scheme,
+		Mapper:     opts.Mapper,
+		HTTPClient: opts.HTTPClient,
+	}
+	if clientOptions.Scheme == nil {
+		clientOptions.Scheme = scheme
+	}
+	return client.New(config, clientOptions)
+}
+
 func Client() (client.Client, error) {
 	restConfig, err := ctrl.GetConfig()
 	if err != nil {
 		return nil, err
 	}
 
-	return newClient(restConfig, "", false)
+	return New(restConfig, Options{})
 }
 
 func ClusterClient(cluster string) (client.Client, error) {
@@ -52,7 +131,7 @@ func ClusterClient(cluster string) (client.Client, error) {
 		return nil, err
 	}
 
-	return newClient(restConfig, cluster, false)
+	return New(restConfig, Options{Cluster: cluster})
 }
 
 func ProxyClusterClient(cluster string) (client.Client, error) {
@@ -60,7 +139,7 @@ func ProxyClusterClient(cluster string) (client.Client, error) {
 	if err != nil {
 		return nil, err
 	}
-	return newClient(restConfig, cluster, true)
+	return New(restConfig, Options{Cluster: cluster, Proxy: true})
 }
 
 func GetClient(restConfig *rest.Config, clusters ...string) (client.Client, error) {
@@ -68,54 +147,34 @@ func GetClient(restConfig *rest.Config, clusters ...string) (client.Client, erro
 	if len(clusters) != 0 {
 		cluster = clusters[0]
 	}
-	return newClient(restConfig, cluster, false)
-}
-
-func newClient(restConfig *rest.Config, cluster string, proxyWithPath bool) (client.Client, error) {
-	var err error
-	restConfig, err = configFor(restConfig, cluster, proxyWithPath)
-	if err != nil {
-		return nil, err
-	}
-
-	scheme := runtime.NewScheme()
-	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
-	utilruntime.Must(clusterv1alpha2.AddToScheme(scheme))
-
-	c, err := client.New(restConfig, client.Options{
-		Scheme: scheme,
-	})
-	if err != nil {
-		return nil, err
-	}
-
-	return c, nil
+	return New(restConfig, Options{Cluster: cluster})
 }
 
 func ConfigFor(cfg *rest.Config) (*rest.Config, error) {
-	return configFor(cfg, "", false)
+	return configFor(cfg, "", "", false)
 }
 
 func ClusterConfigFor(cfg *rest.Config, cluster string) (*rest.Config, error) {
-	return configFor(cfg, cluster, false)
+	return configFor(cfg, "", cluster, false)
 }
 
 func ProxyClusterConfigFor(cfg *rest.Config, cluster string) (*rest.Config, error) {
-	return configFor(cfg, cluster, true)
+	return configFor(cfg, "", cluster, true)
 }
 
-func configFor(cfg *rest.Config, cluster string, withPath bool) (*rest.Config, error) {
+func configFor(cfg *rest.Config, basePath, cluster string, withPath bool) (*rest.Config, error) {
 	configShallowCopy := *cfg
 
 	// reset clusterpedia api path
-	if err := SetConfigDefaults(&configShallowCopy); err != nil {
+	if err := SetConfigDefaultsWithBasePath(&configShallowCopy, basePath); err != nil {
 		return nil, err
 	}
 	if cluster != "" {
-		configShallowCopy.Host += constants.ClusterAPIPath + cluster
-		if withPath {
-			configShallowCopy.Host += "/proxy"
+		host, err := clusterpediaHost(configShallowCopy.Host, "", cluster, withPath)
+		if err != nil {
+			return nil, err
 		}
+		configShallowCopy.Host = host
 	}
 	return &configShallowCopy, nil
 }
@@ -148,8 +207,23 @@ func NewClusterForConfig(cfg *rest.Config, cluster string) (kubernetes.Interface
 	return kubeClient, nil
 }
 
+// SetConfigDefaults points the config at clusterpedia and fills the defaults.
+// It is idempotent, a config already pointing at clusterpedia or at a cluster
+// of clusterpedia is reset to the clusterpedia api path.
 func SetConfigDefaults(config *rest.Config) error {
-	config.Host += constants.ClusterPediaAPIPath
+	return SetConfigDefaultsWithBasePath(config, "")
+}
+
+// SetConfigDefaultsWithBasePath is like SetConfigDefaults for clusterpedia
+// served under basePath, the base path is not repeated if config.Host already
+// ends with it.
+func SetConfigDefaultsWithBasePath(config *rest.Config, basePath string) error {
+	host, err := clusterpediaHost(config.Host, basePath, "", false)
+	if err != nil {
+		return err
+	}
+	config.Host = host
+
 	if config.Timeout == 0 {
 		config.Timeout = DefaultTimeoutSeconds * time.Second
 	}
@@ -165,3 +239,49 @@ func SetConfigDefaults(config *rest.Config) error {
 
 	return nil
 }
+
+// clusterpediaHost returns the host of the clusterpedia api, or of the cluster's
+// api when cluster is set. Any clusterpedia path already in host is replaced,
+// while the path in front of it is kept as the base path.
+func clusterpediaHost(host, basePath, cluster string, proxy bool) (string, error) {
+	if host == "" {
+		return "", errors.New("host must be set")
+	}
+
+	// host may be a host:port pair without scheme
+	raw := host
+	hasScheme := strings.Contains(host, "://")
+	if !hasScheme {
+		raw = "//" + host
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", fmt.Errorf("invalid host %q: %w", host, err)
+	}
+
+	path := u.Path
+	if i := strings.Index(path, constants.ClusterPediaOriginAPIPath); i >= 0 {
+		path = path[:i]
+	}
+	path = strings.TrimRight(path, "/")
+
+	if basePath = strings.Trim(basePath, "/"); basePath != "" {
+		if basePath = "/" + basePath; !strings.HasSuffix(path, basePath) {
+			path += basePath
+		}
+	}
+
+	path += constants.ClusterPediaAPIPath
+	if cluster != "" {
+		path += constants.ClusterAPIPath + cluster
+		if proxy {
+			path += "/proxy"
+		}
+	}
+	u.Path, u.RawPath = path, ""
+
+	if hasScheme {
+		return u.String(), nil
+	}
+	return strings.TrimPrefix(u.String(), "//"), nil
+}
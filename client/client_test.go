@@ -0,0 +1,172 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"testing"
+
+	"k8s.io/client-go/rest"
+)
+
+func TestSetConfigDefaults(t *testing.T) {
+	testCase := []struct {
+		name       string
+		host       string
+		basePath   string
+		expectHost string
+	}{
+		{
+			name:       "host",
+			host:       "https://10.6.0.1:6443",
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "host with trailing slash",
+			host:       "https://10.6.0.1:6443/",
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "host without scheme",
+			host:       "10.6.0.1:6443",
+			expectHost: "10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "clusterpedia host",
+			host:       "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "clusterpedia origin host",
+			host:       "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1",
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "cluster host",
+			host:       "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01/proxy",
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "host with sub-path",
+			host:       "https://gateway.example.com/k8s",
+			expectHost: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "clusterpedia host with sub-path",
+			host:       "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+			expectHost: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "base path",
+			host:       "https://gateway.example.com",
+			basePath:   "/k8s/",
+			expectHost: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name:       "base path in host",
+			host:       "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+			basePath:   "k8s",
+			expectHost: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+		},
+	}
+
+	for _, test := range testCase {
+		t.Run(test.name, func(t *testing.T) {
+			config := &rest.Config{Host: test.host, APIPath: "/apis"}
+			if err := SetConfigDefaultsWithBasePath(config, test.basePath); err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if config.Host != test.expectHost {
+				t.Errorf("Unexpect host: %s, expect: %s", config.Host, test.expectHost)
+			}
+			if config.APIPath != "/apis" {
+				t.Errorf("Unexpect api path: %s, expect: %s", config.APIPath, "/apis")
+			}
+
+			// set defaults again
+			if err := SetConfigDefaultsWithBasePath(config, test.basePath); err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if config.Host != test.expectHost {
+				t.Errorf("Unexpect host after setting defaults twice: %s, expect: %s", config.Host, test.expectHost)
+			}
+		})
+	}
+}
+
+func TestConfigFor(t *testing.T) {
+	testCase := []struct {
+		name       string
+		host       string
+		config     func(*rest.Config) (*rest.Config, error)
+		expectHost string
+	}{
+		{
+			name:       "clusterpedia",
+			host:       "https://10.6.0.1:6443",
+			config:     ConfigFor,
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources",
+		},
+		{
+			name: "cluster",
+			host: "https://10.6.0.1:6443",
+			config: func(cfg *rest.Config) (*rest.Config, error) {
+				return ClusterConfigFor(cfg, "cluster-01")
+			},
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01",
+		},
+		{
+			name: "proxy cluster",
+			host: "https://10.6.0.1:6443",
+			config: func(cfg *rest.Config) (*rest.Config, error) {
+				return ProxyClusterConfigFor(cfg, "cluster-01")
+			},
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01/proxy",
+		},
+		{
+			name: "switch cluster",
+			host: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01",
+			config: func(cfg *rest.Config) (*rest.Config, error) {
+				return ClusterConfigFor(cfg, "cluster-02")
+			},
+			expectHost: "https://10.6.0.1:6443/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-02",
+		},
+		{
+			name: "cluster with sub-path",
+			host: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources",
+			config: func(cfg *rest.Config) (*rest.Config, error) {
+				return ProxyClusterConfigFor(cfg, "cluster-01")
+			},
+			expectHost: "https://gateway.example.com/k8s/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01/proxy",
+		},
+	}
+
+	for _, test := range testCase {
+		t.Run(test.name, func(t *testing.T) {
+			cfg := &rest.Config{Host: test.host}
+			config, err := test.config(cfg)
+			if err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if config.Host != test.expectHost {
+				t.Errorf("Unexpect host: %s, expect: %s", config.Host, test.expectHost)
+			}
+			if cfg.Host != test.host {
+				t.Errorf("Unexpect modification of the origin config: %s", cfg.Host)
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned"
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+const DefaultMultiClusterConcurrency = 10
+
+// MultiClusterOptions are the options for creating a MultiClusterClient.
+type MultiClusterOptions struct {
+	// Options are used for the client of every cluster, Cluster is ignored.
+	Options
+
+	// Clusters are the clusters the calls are executed against. If it is
+	// empty, the clusters are the PediaClusters matching Selector.
+	Clusters []string
+	Selector labels.Selector
+
+	// Concurrency bounds the number of clusters called at the same time,
+	// defaults to DefaultMultiClusterConcurrency.
+	Concurrency int
+}
+
+// MultiClusterClient executes a call against every cluster with its own
+// client and merges the results.
+type MultiClusterClient struct {
+	clients     *clusterClients
+	clusters    []string
+	selector    labels.Selector
+	concurrency int
+
+	pediaClusters versioned.Interface
+}
+
+// ClusterErrors holds the error of every cluster a multi-cluster call failed on.
+type ClusterErrors map[string]error
+
+func (e ClusterErrors) Error() string {
+	clusters := make([]string, 0, len(e))
+	for cluster := range e {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	msgs := make([]string, 0, len(clusters))
+	for _, cluster := range clusters {
+		msgs = append(msgs, fmt.Sprintf("cluster %s: %v", cluster, e[cluster]))
+	}
+	return strings.Join(msgs, "; ")
+}
+
+func NewMultiClusterClient(cfg *rest.Config, opts MultiClusterOptions) (*MultiClusterClient, error) {
+	c := &MultiClusterClient{
+		clients:     newClusterClients(cfg, opts.Options),
+		clusters:    opts.Clusters,
+		selector:    opts.Selector,
+		concurrency: opts.Concurrency,
+	}
+	if c.concurrency <= 0 {
+		c.concurrency = DefaultMultiClusterConcurrency
+	}
+
+	if len(c.clusters) == 0 {
+		if c.selector == nil {
+			c.selector = labels.Everything()
+		}
+
+		var err error
+		if c.pediaClusters, err = versioned.NewForConfig(cfg); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// Clusters returns the clusters the calls are executed against.
+func (c *MultiClusterClient) Clusters(ctx context.Context) ([]string, error) {
+	if len(c.clusters) != 0 {
+		return c.clusters, nil
+	}
+
+	pediaClusters, err := c.pediaClusters.ClusterV1alpha2().PediaClusters().List(ctx, metav1.ListOptions{LabelSelector: c.selector.String()})
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]string, 0, len(pediaClusters.Items))
+	for _, cluster := range pediaClusters.Items {
+		clusters = append(clusters, cluster.Name)
+	}
+	return clusters, nil
+}
+
+// ClusterClient returns the client of the cluster, clients are created on
+// first use and reused afterwards.
+func (c *MultiClusterClient) ClusterClient(cluster string) (client.Client, error) {
+	return c.clients.Get(cluster)
+}
+
+// Do calls fn with the client of every cluster, with at most Concurrency
+// calls running at the same time. The failed clusters are returned as ClusterErrors.
+func (c *MultiClusterClient) Do(ctx context.Context, fn func(ctx context.Context, cluster string, c client.Client) error) error {
+	clusters, err := c.Clusters(ctx)
+	if err != nil {
+		return err
+	}
+
+	var (
+		wg   sync.WaitGroup
+		lock sync.Mutex
+		errs = make(ClusterErrors)
+	)
+	sem := make(chan struct{}, c.concurrency)
+	for _, cluster := range clusters {
+		cluster := cluster
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+
+			err := ctx.Err()
+			if err == nil {
+				var cc client.Client
+				if cc, err = c.ClusterClient(cluster); err == nil {
+					err = fn(ctx, cluster, cc)
+				}
+			}
+			if err != nil {
+				lock.Lock()
+				errs[cluster] = err
+				lock.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) != 0 {
+		return errs
+	}
+	return nil
+}
+
+// List lists the objects of every cluster and merges them into list, every item
+// is annotated with its cluster. If some clusters fail, list holds the items of
+// the other clusters and the failures are returned as ClusterErrors.
+func (c *MultiClusterClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	var lock sync.Mutex
+	items := make(map[string][]runtime.Object)
+	err := c.Do(ctx, func(ctx context.Context, cluster string, cc client.Client) error {
+		clusterList := list.DeepCopyObject().(client.ObjectList)
+		if err := cc.List(ctx, clusterList, opts...); err != nil {
+			return err
+		}
+
+		objs, err := meta.ExtractList(clusterList)
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if err := annotateCluster(obj, cluster); err != nil {
+				return err
+			}
+		}
+
+		lock.Lock()
+		items[cluster] = objs
+		lock.Unlock()
+		return nil
+	})
+	if err != nil {
+		if _, ok := err.(ClusterErrors); !ok {
+			return err
+		}
+	}
+
+	clusters := make([]string, 0, len(items))
+	for cluster := range items {
+		clusters = append(clusters, cluster)
+	}
+	sort.Strings(clusters)
+
+	var merged []runtime.Object
+	for _, cluster := range clusters {
+		merged = append(merged, items[cluster]...)
+	}
+	list.SetResourceVersion("")
+	list.SetContinue("")
+	list.SetRemainingItemCount(nil)
+	if setErr := meta.SetList(list, merged); setErr != nil {
+		return setErr
+	}
+	return err
+}
+
+// clusterClients creates the client of a cluster on first use and reuses it afterwards.
+type clusterClients struct {
+	config  *rest.Config
+	options Options
+
+	lock    sync.Mutex
+	clients map[string]client.Client
+}
+
+func newClusterClients(cfg *rest.Config, opts Options) *clusterClients {
+	return &clusterClients{config: cfg, options: opts, clients: make(map[string]client.Client)}
+}
+
+func (c *clusterClients) Get(cluster string) (client.Client, error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if cc, ok := c.clients[cluster]; ok {
+		return cc, nil
+	}
+
+	opts := c.options
+	opts.Cluster = cluster
+	cc, err := New(c.config, opts)
+	if err != nil {
+		return nil, err
+	}
+	c.clients[cluster] = cc
+	return cc, nil
+}
+
+func annotateCluster(obj runtime.Object, cluster string) error {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return err
+	}
+	if shadow.ClusterOf(accessor) == "" {
+		shadow.SetCluster(accessor, cluster)
+	}
+	return nil
+}
@@ -0,0 +1,86 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+func TestMultiClusterClientList(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, constants.ClusterPediaAPIPath+constants.ClusterAPIPath)
+		cluster, path, _ := strings.Cut(path, "/")
+		if path != "api/v1/pods" || cluster == "broken" {
+			http.Error(w, "unavailable", http.StatusInternalServerError)
+			return
+		}
+
+		pods := &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"}}
+		for _, name := range []string{"pod-a", "pod-b"} {
+			pods.Items = append(pods.Items, corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(pods)
+	}))
+	defer server.Close()
+
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+
+	c, err := NewMultiClusterClient(&rest.Config{Host: server.URL}, MultiClusterOptions{
+		Options:     Options{Mapper: mapper},
+		Clusters:    []string{"cluster-02", "broken", "cluster-01"},
+		Concurrency: 2,
+	})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	pods := &corev1.PodList{}
+	err = c.List(context.TODO(), pods)
+
+	var errs ClusterErrors
+	if !errors.As(err, &errs) {
+		t.Fatalf("Unexpect error: %v, expect cluster errors", err)
+	}
+	if _, ok := errs["broken"]; !ok || len(errs) != 1 {
+		t.Errorf("Unexpect cluster errors: %v", errs)
+	}
+
+	expect := []string{"cluster-01/pod-a", "cluster-01/pod-b", "cluster-02/pod-a", "cluster-02/pod-b"}
+	if len(pods.Items) != len(expect) {
+		t.Fatalf("Unexpect items: %d, expect: %d", len(pods.Items), len(expect))
+	}
+	for i, pod := range pods.Items {
+		if key := pod.Annotations[constants.ShadowAnnotationClusterName] + "/" + pod.Name; key != expect[i] {
+			t.Errorf("Unexpect item: %s, expect: %s", key, expect[i])
+		}
+	}
+}
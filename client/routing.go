@@ -0,0 +1,226 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+type clusterContextKey struct{}
+
+// WithCluster returns a copy of ctx that routes the calls of a routing client
+// to cluster, it takes precedence over the cluster annotation of the object.
+func WithCluster(ctx context.Context, cluster string) context.Context {
+	return context.WithValue(ctx, clusterContextKey{}, cluster)
+}
+
+// ClusterFrom returns the cluster set in ctx by WithCluster.
+func ClusterFrom(ctx context.Context) (string, bool) {
+	cluster, ok := ctx.Value(clusterContextKey{}).(string)
+	return cluster, ok && cluster != ""
+}
+
+var _ client.Client = &routingClient{}
+
+// routingClient serves reads from clusterpedia and sends writes through the
+// proxy path to the member cluster of the object.
+type routingClient struct {
+	client.Client
+
+	readers *clusterClients
+	writers *clusterClients
+}
+
+// NewRoutingClient returns a client.Client reading from clusterpedia and writing
+// to the member clusters. Reads go to the cluster set in the context, or to all
+// clusters if there is none. Writes go to the cluster set in the context or in
+// the shadow.clusterpedia.io/cluster-name annotation of the object.
+// opts.Cluster and opts.Proxy are ignored.
+func NewRoutingClient(cfg *rest.Config, opts Options) (client.Client, error) {
+	opts.Cluster, opts.Proxy = "", false
+	c, err := New(cfg, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	writerOptions := opts
+	writerOptions.Proxy = true
+	return &routingClient{
+		Client:  c,
+		readers: newClusterClients(cfg, opts),
+		writers: newClusterClients(cfg, writerOptions),
+	}, nil
+}
+
+func (c *routingClient) reader(ctx context.Context) (client.Client, error) {
+	if cluster, ok := ClusterFrom(ctx); ok {
+		return c.readers.Get(cluster)
+	}
+	return c.Client, nil
+}
+
+func (c *routingClient) writer(ctx context.Context, obj client.Object) (client.Client, string, error) {
+	cluster, ok := ClusterFrom(ctx)
+	if !ok {
+		cluster = shadow.ClusterOf(obj)
+	}
+	if cluster == "" {
+		return nil, "", fmt.Errorf("cluster of %s %s is unknown, set it with WithCluster or the %s annotation",
+			c.kindFor(obj), client.ObjectKeyFromObject(obj), constants.ShadowAnnotationClusterName)
+	}
+
+	w, err := c.writers.Get(cluster)
+	return w, cluster, err
+}
+
+// write strips the shadow annotations from obj before it is sent to the member
+// cluster, and restores them with the written cluster once the call returns.
+func write(obj client.Object, cluster string, fn func() error) error {
+	stripped := shadow.StripAnnotations(obj)
+	defer func() {
+		shadow.RestoreAnnotations(obj, stripped)
+		shadow.SetCluster(obj, cluster)
+	}()
+	return fn()
+}
+
+func (c *routingClient) kindFor(obj runtime.Object) string {
+	gvk, err := c.GroupVersionKindFor(obj)
+	if err != nil {
+		return fmt.Sprintf("%T", obj)
+	}
+	return gvk.Kind
+}
+
+func (c *routingClient) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	r, err := c.reader(ctx)
+	if err != nil {
+		return err
+	}
+	return r.Get(ctx, key, obj, opts...)
+}
+
+func (c *routingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	r, err := c.reader(ctx)
+	if err != nil {
+		return err
+	}
+	return r.List(ctx, list, opts...)
+}
+
+func (c *routingClient) Create(ctx context.Context, obj client.Object, opts ...client.CreateOption) error {
+	w, cluster, err := c.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return write(obj, cluster, func() error { return w.Create(ctx, obj, opts...) })
+}
+
+func (c *routingClient) Delete(ctx context.Context, obj client.Object, opts ...client.DeleteOption) error {
+	w, _, err := c.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return w.Delete(ctx, obj, opts...)
+}
+
+func (c *routingClient) Update(ctx context.Context, obj client.Object, opts ...client.UpdateOption) error {
+	w, cluster, err := c.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return write(obj, cluster, func() error { return w.Update(ctx, obj, opts...) })
+}
+
+func (c *routingClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.PatchOption) error {
+	w, cluster, err := c.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return write(obj, cluster, func() error { return w.Patch(ctx, obj, patch, opts...) })
+}
+
+func (c *routingClient) DeleteAllOf(ctx context.Context, obj client.Object, opts ...client.DeleteAllOfOption) error {
+	w, _, err := c.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return w.DeleteAllOf(ctx, obj, opts...)
+}
+
+func (c *routingClient) Status() client.SubResourceWriter {
+	return c.SubResource("status")
+}
+
+func (c *routingClient) SubResource(subResource string) client.SubResourceClient {
+	return &routingSubResourceClient{client: c, subResource: subResource}
+}
+
+// routingSubResourceClient sends the subresource requests to the member cluster,
+// clusterpedia does not serve subresources.
+type routingSubResourceClient struct {
+	client      *routingClient
+	subResource string
+}
+
+func (sc *routingSubResourceClient) writer(ctx context.Context, obj client.Object) (client.SubResourceClient, string, error) {
+	w, cluster, err := sc.client.writer(ctx, obj)
+	if err != nil {
+		return nil, "", err
+	}
+	return w.SubResource(sc.subResource), cluster, nil
+}
+
+func (sc *routingSubResourceClient) Get(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceGetOption) error {
+	w, _, err := sc.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return w.Get(ctx, obj, subResource, opts...)
+}
+
+func (sc *routingSubResourceClient) Create(ctx context.Context, obj client.Object, subResource client.Object, opts ...client.SubResourceCreateOption) error {
+	w, _, err := sc.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return w.Create(ctx, obj, subResource, opts...)
+}
+
+func (sc *routingSubResourceClient) Update(ctx context.Context, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+	w, cluster, err := sc.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return write(obj, cluster, func() error { return w.Update(ctx, obj, opts...) })
+}
+
+func (sc *routingSubResourceClient) Patch(ctx context.Context, obj client.Object, patch client.Patch, opts ...client.SubResourcePatchOption) error {
+	w, cluster, err := sc.writer(ctx, obj)
+	if err != nil {
+		return err
+	}
+	return write(obj, cluster, func() error { return w.Patch(ctx, obj, patch, opts...) })
+}
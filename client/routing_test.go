@@ -0,0 +1,276 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+// routingServer records the requests and serves a pod for every path, the
+// created and updated pods are echoed back.
+type routingServer struct {
+	*httptest.Server
+
+	lock     sync.Mutex
+	requests []string
+	bodies   []*corev1.Pod
+}
+
+func newRoutingServer() *routingServer {
+	s := &routingServer{}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pod := &corev1.Pod{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Pod"},
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-a", ResourceVersion: "1"},
+		}
+		if strings.Contains(r.URL.Path, constants.ClusterAPIPath+"broken/") {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			pod.Annotations = map[string]string{constants.ShadowAnnotationClusterName: "cluster-01"}
+		case http.MethodPost, http.MethodPut:
+			if err := json.NewDecoder(r.Body).Decode(pod); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			pod.ResourceVersion = "2"
+		}
+
+		s.lock.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.bodies = append(s.bodies, pod.DeepCopy())
+		s.lock.Unlock()
+
+		var obj interface{} = pod
+		if strings.HasSuffix(r.URL.Path, "/pods") && r.Method == http.MethodGet {
+			obj = &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"}, Items: []corev1.Pod{*pod}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(obj)
+	}))
+	return s
+}
+
+// last returns the last request and the pod it was answered with.
+func (s *routingServer) last() (string, *corev1.Pod) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if len(s.requests) == 0 {
+		return "", nil
+	}
+	return s.requests[len(s.requests)-1], s.bodies[len(s.bodies)-1]
+}
+
+func newRoutingTestClient(t *testing.T, server *routingServer) client.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(corev1.SchemeGroupVersion.WithKind("Pod"), meta.RESTScopeNamespace)
+
+	c, err := NewRoutingClient(&rest.Config{Host: server.URL, ContentConfig: rest.ContentConfig{ContentType: "application/json"}}, Options{Mapper: mapper})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	return c
+}
+
+func podOf(cluster string) *corev1.Pod {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "pod-a", ResourceVersion: "1"}}
+	if cluster != "" {
+		pod.Annotations = map[string]string{constants.ShadowAnnotationClusterName: cluster}
+	}
+	return pod
+}
+
+func TestRoutingClient(t *testing.T) {
+	server := newRoutingServer()
+	defer server.Close()
+	c := newRoutingTestClient(t, server)
+
+	const (
+		resources = constants.ClusterPediaAPIPath
+		pod       = "/api/v1/namespaces/default/pods/pod-a"
+	)
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		call   func(ctx context.Context) error
+		expect string
+	}{
+		{
+			name: "get from clusterpedia",
+			ctx:  context.TODO(),
+			call: func(ctx context.Context) error {
+				return c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pod-a"}, &corev1.Pod{})
+			},
+			expect: "GET " + resources + pod,
+		},
+		{
+			name: "get from cluster of context",
+			ctx:  WithCluster(context.TODO(), "cluster-02"),
+			call: func(ctx context.Context) error {
+				return c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "pod-a"}, &corev1.Pod{})
+			},
+			expect: "GET " + resources + "/clusters/cluster-02" + pod,
+		},
+		{
+			name:   "list from clusterpedia",
+			ctx:    context.TODO(),
+			call:   func(ctx context.Context) error { return c.List(ctx, &corev1.PodList{}, client.InNamespace("default")) },
+			expect: "GET " + resources + "/api/v1/namespaces/default/pods",
+		},
+		{
+			name:   "update to cluster of annotation",
+			ctx:    context.TODO(),
+			call:   func(ctx context.Context) error { return c.Update(ctx, podOf("cluster-01")) },
+			expect: "PUT " + resources + "/clusters/cluster-01/proxy" + pod,
+		},
+		{
+			name:   "update to cluster of context over annotation",
+			ctx:    WithCluster(context.TODO(), "cluster-02"),
+			call:   func(ctx context.Context) error { return c.Update(ctx, podOf("cluster-01")) },
+			expect: "PUT " + resources + "/clusters/cluster-02/proxy" + pod,
+		},
+		{
+			name: "create to cluster of context",
+			ctx:  WithCluster(context.TODO(), "cluster-02"),
+			call: func(ctx context.Context) error {
+				pod := podOf("")
+				pod.ResourceVersion = ""
+				return c.Create(ctx, pod)
+			},
+			expect: "POST " + resources + "/clusters/cluster-02/proxy/api/v1/namespaces/default/pods",
+		},
+		{
+			name:   "delete to cluster of annotation",
+			ctx:    context.TODO(),
+			call:   func(ctx context.Context) error { return c.Delete(ctx, podOf("cluster-01")) },
+			expect: "DELETE " + resources + "/clusters/cluster-01/proxy" + pod,
+		},
+		{
+			name: "patch to cluster of annotation",
+			ctx:  context.TODO(),
+			call: func(ctx context.Context) error {
+				return c.Patch(ctx, podOf("cluster-01"), client.RawPatch("application/merge-patch+json", []byte(`{}`)))
+			},
+			expect: "PATCH " + resources + "/clusters/cluster-01/proxy" + pod,
+		},
+		{
+			name:   "status update to cluster of annotation",
+			ctx:    context.TODO(),
+			call:   func(ctx context.Context) error { return c.Status().Update(ctx, podOf("cluster-01")) },
+			expect: "PUT " + resources + "/clusters/cluster-01/proxy" + pod + "/status",
+		},
+		{
+			name: "status patch to cluster of context",
+			ctx:  WithCluster(context.TODO(), "cluster-02"),
+			call: func(ctx context.Context) error {
+				return c.Status().Patch(ctx, podOf("cluster-01"), client.RawPatch("application/merge-patch+json", []byte(`{}`)))
+			},
+			expect: "PATCH " + resources + "/clusters/cluster-02/proxy" + pod + "/status",
+		},
+		{
+			name: "eviction to cluster of annotation",
+			ctx:  context.TODO(),
+			call: func(ctx context.Context) error {
+				return c.SubResource("eviction").Create(ctx, podOf("cluster-01"), &corev1.Pod{})
+			},
+			expect: "POST " + resources + "/clusters/cluster-01/proxy" + pod + "/eviction",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.call(tt.ctx); err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if request, _ := server.last(); request != tt.expect {
+				t.Errorf("Unexpect request: %s, expect: %s", request, tt.expect)
+			}
+		})
+	}
+}
+
+func TestRoutingClientWrite(t *testing.T) {
+	server := newRoutingServer()
+	defer server.Close()
+	c := newRoutingTestClient(t, server)
+
+	for _, cluster := range []string{"cluster-01", "broken"} {
+		pod := podOf("cluster-01")
+		pod.Annotations[constants.ShadowAnnotationGroupVersionResource] = "v1/pods"
+		pod.Annotations["owner"] = "team-a"
+		annotations := pod.Annotations
+
+		err := c.Update(WithCluster(context.TODO(), cluster), pod)
+		if (err != nil) != (cluster == "broken") {
+			t.Fatalf("%s: Unexpect error: %v", cluster, err)
+		}
+		if len(annotations) != 3 || annotations[constants.ShadowAnnotationGroupVersionResource] != "v1/pods" {
+			t.Errorf("%s: Unexpect change of the annotations of the caller: %v", cluster, annotations)
+		}
+		if pod.Annotations[constants.ShadowAnnotationClusterName] != cluster ||
+			pod.Annotations[constants.ShadowAnnotationGroupVersionResource] != "v1/pods" || pod.Annotations["owner"] != "team-a" {
+			t.Errorf("%s: Unexpect annotations: %v, expect the shadow annotations are restored", cluster, pod.Annotations)
+		}
+		if err != nil {
+			continue
+		}
+
+		_, sent := server.last()
+		if len(sent.Annotations) != 1 || sent.Annotations["owner"] != "team-a" {
+			t.Errorf("Unexpect annotations sent to the member cluster: %v", sent.Annotations)
+		}
+		if pod.ResourceVersion != "2" {
+			t.Errorf("Unexpect pod: %+v, expect the updated pod", pod.ObjectMeta)
+		}
+	}
+}
+
+func TestRoutingClientUnknownCluster(t *testing.T) {
+	server := newRoutingServer()
+	defer server.Close()
+	c := newRoutingTestClient(t, server)
+
+	writes := map[string]func() error{
+		"create": func() error { return c.Create(context.TODO(), podOf("")) },
+		"update": func() error { return c.Update(context.TODO(), podOf("")) },
+		"delete": func() error { return c.Delete(context.TODO(), podOf("")) },
+		"status": func() error { return c.Status().Update(context.TODO(), podOf("")) },
+	}
+	for name, write := range writes {
+		if err := write(); err == nil || !strings.Contains(err.Error(), "cluster of Pod default/pod-a is unknown") {
+			t.Errorf("%s: Unexpect error: %v, expect unknown cluster", name, err)
+		}
+	}
+	if request, _ := server.last(); request != "" {
+		t.Errorf("Unexpect request: %s", request)
+	}
+}
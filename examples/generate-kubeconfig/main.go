@@ -0,0 +1,69 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/spf13/pflag"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/clusterpedia-io/client-go/tools/kubeconfig"
+)
+
+func main() {
+	var (
+		kubeconfigPath string
+		output         string
+		opts           kubeconfig.Options
+	)
+	pflag.StringVar(&kubeconfigPath, "kubeconfig", "", "path to the base kubeconfig, defaults to the kubectl loading rules")
+	pflag.StringVar(&opts.Context, "context", "", "context of the clusterpedia host cluster, defaults to the current context")
+	pflag.StringVar(&opts.ContextPrefix, "prefix", kubeconfig.DefaultContextPrefix, "prefix of the generated cluster contexts")
+	pflag.BoolVar(&opts.Proxy, "proxy", false, "also generate the proxy contexts of clusters")
+	pflag.BoolVar(&opts.Overwrite, "overwrite", false, "replace the contexts of the kubeconfig with the same names, such as the contexts generated before")
+	pflag.StringVarP(&output, "output", "o", "", "file to write the kubeconfig to, defaults to stdout")
+	pflag.Parse()
+
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = kubeconfigPath
+	base, err := loadingRules.Load()
+	if err != nil {
+		log.Fatalf("failed to load kubeconfig: %v", err)
+	}
+
+	config, err := kubeconfig.GenerateForPediaClusters(context.TODO(), base, opts)
+	if err != nil {
+		log.Fatalf("failed to generate kubeconfig: %v", err)
+	}
+
+	if output != "" {
+		if err := clientcmd.WriteToFile(*config, output); err != nil {
+			log.Fatalf("failed to write kubeconfig: %v", err)
+		}
+		return
+	}
+
+	data, err := clientcmd.Write(*config)
+	if err != nil {
+		log.Fatalf("failed to encode kubeconfig: %v", err)
+	}
+	fmt.Fprint(os.Stdout, string(data))
+}
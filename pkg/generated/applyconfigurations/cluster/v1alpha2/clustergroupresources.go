@@ -0,0 +1,45 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// ClusterGroupResourcesApplyConfiguration represents an declarative configuration of the ClusterGroupResources type for use
+// with apply.
+type ClusterGroupResourcesApplyConfiguration struct {
+	Group     *string  `json:"group,omitempty"`
+	Versions  []string `json:"versions,omitempty"`
+	Resources []string `json:"resources,omitempty"`
+}
+
+// ClusterGroupResourcesApplyConfiguration constructs an declarative configuration of the ClusterGroupResources type for use with
+// apply.
+func ClusterGroupResources() *ClusterGroupResourcesApplyConfiguration {
+	return &ClusterGroupResourcesApplyConfiguration{}
+}
+
+// WithGroup sets the Group field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Group field is set to the value of the last call.
+func (b *ClusterGroupResourcesApplyConfiguration) WithGroup(value string) *ClusterGroupResourcesApplyConfiguration {
+	b.Group = &value
+	return b
+}
+
+// WithVersions adds the given value to the Versions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Versions field.
+func (b *ClusterGroupResourcesApplyConfiguration) WithVersions(values ...string) *ClusterGroupResourcesApplyConfiguration {
+	for i := range values {
+		b.Versions = append(b.Versions, values[i])
+	}
+	return b
+}
+
+// WithResources adds the given value to the Resources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Resources field.
+func (b *ClusterGroupResourcesApplyConfiguration) WithResources(values ...string) *ClusterGroupResourcesApplyConfiguration {
+	for i := range values {
+		b.Resources = append(b.Resources, values[i])
+	}
+	return b
+}
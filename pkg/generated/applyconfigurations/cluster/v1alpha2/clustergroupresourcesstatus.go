@@ -0,0 +1,37 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// ClusterGroupResourcesStatusApplyConfiguration represents an declarative configuration of the ClusterGroupResourcesStatus type for use
+// with apply.
+type ClusterGroupResourcesStatusApplyConfiguration struct {
+	Group     *string                                   `json:"group,omitempty"`
+	Resources []ClusterResourceStatusApplyConfiguration `json:"resources,omitempty"`
+}
+
+// ClusterGroupResourcesStatusApplyConfiguration constructs an declarative configuration of the ClusterGroupResourcesStatus type for use with
+// apply.
+func ClusterGroupResourcesStatus() *ClusterGroupResourcesStatusApplyConfiguration {
+	return &ClusterGroupResourcesStatusApplyConfiguration{}
+}
+
+// WithGroup sets the Group field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Group field is set to the value of the last call.
+func (b *ClusterGroupResourcesStatusApplyConfiguration) WithGroup(value string) *ClusterGroupResourcesStatusApplyConfiguration {
+	b.Group = &value
+	return b
+}
+
+// WithResources adds the given value to the Resources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Resources field.
+func (b *ClusterGroupResourcesStatusApplyConfiguration) WithResources(values ...*ClusterResourceStatusApplyConfiguration) *ClusterGroupResourcesStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithResources")
+		}
+		b.Resources = append(b.Resources, *values[i])
+	}
+	return b
+}
@@ -0,0 +1,55 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// ClusterResourceStatusApplyConfiguration represents an declarative configuration of the ClusterResourceStatus type for use
+// with apply.
+type ClusterResourceStatusApplyConfiguration struct {
+	Name           *string                                          `json:"name,omitempty"`
+	Kind           *string                                          `json:"kind,omitempty"`
+	Namespaced     *bool                                            `json:"namespaced,omitempty"`
+	SyncConditions []ClusterResourceSyncConditionApplyConfiguration `json:"syncConditions,omitempty"`
+}
+
+// ClusterResourceStatusApplyConfiguration constructs an declarative configuration of the ClusterResourceStatus type for use with
+// apply.
+func ClusterResourceStatus() *ClusterResourceStatusApplyConfiguration {
+	return &ClusterResourceStatusApplyConfiguration{}
+}
+
+// WithName sets the Name field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Name field is set to the value of the last call.
+func (b *ClusterResourceStatusApplyConfiguration) WithName(value string) *ClusterResourceStatusApplyConfiguration {
+	b.Name = &value
+	return b
+}
+
+// WithKind sets the Kind field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Kind field is set to the value of the last call.
+func (b *ClusterResourceStatusApplyConfiguration) WithKind(value string) *ClusterResourceStatusApplyConfiguration {
+	b.Kind = &value
+	return b
+}
+
+// WithNamespaced sets the Namespaced field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Namespaced field is set to the value of the last call.
+func (b *ClusterResourceStatusApplyConfiguration) WithNamespaced(value bool) *ClusterResourceStatusApplyConfiguration {
+	b.Namespaced = &value
+	return b
+}
+
+// WithSyncConditions adds the given value to the SyncConditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the SyncConditions field.
+func (b *ClusterResourceStatusApplyConfiguration) WithSyncConditions(values ...*ClusterResourceSyncConditionApplyConfiguration) *ClusterResourceStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithSyncConditions")
+		}
+		b.SyncConditions = append(b.SyncConditions, *values[i])
+	}
+	return b
+}
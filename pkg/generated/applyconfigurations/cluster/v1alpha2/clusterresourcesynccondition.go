@@ -0,0 +1,99 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterResourceSyncConditionApplyConfiguration represents an declarative configuration of the ClusterResourceSyncCondition type for use
+// with apply.
+type ClusterResourceSyncConditionApplyConfiguration struct {
+	Version            *string  `json:"version,omitempty"`
+	SyncVersion        *string  `json:"syncVersion,omitempty"`
+	SyncResource       *string  `json:"syncResource,omitempty"`
+	StorageVersion     *string  `json:"storageVersion,omitempty"`
+	StorageResource    *string  `json:"storageResource,omitempty"`
+	Status             *string  `json:"status,omitempty"`
+	Reason             *string  `json:"reason,omitempty"`
+	Message            *string  `json:"message,omitempty"`
+	LastTransitionTime *v1.Time `json:"lastTransitionTime,omitempty"`
+}
+
+// ClusterResourceSyncConditionApplyConfiguration constructs an declarative configuration of the ClusterResourceSyncCondition type for use with
+// apply.
+func ClusterResourceSyncCondition() *ClusterResourceSyncConditionApplyConfiguration {
+	return &ClusterResourceSyncConditionApplyConfiguration{}
+}
+
+// WithVersion sets the Version field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Version field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithVersion(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.Version = &value
+	return b
+}
+
+// WithSyncVersion sets the SyncVersion field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SyncVersion field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithSyncVersion(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.SyncVersion = &value
+	return b
+}
+
+// WithSyncResource sets the SyncResource field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SyncResource field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithSyncResource(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.SyncResource = &value
+	return b
+}
+
+// WithStorageVersion sets the StorageVersion field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the StorageVersion field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithStorageVersion(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.StorageVersion = &value
+	return b
+}
+
+// WithStorageResource sets the StorageResource field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the StorageResource field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithStorageResource(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.StorageResource = &value
+	return b
+}
+
+// WithStatus sets the Status field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Status field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithStatus(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.Status = &value
+	return b
+}
+
+// WithReason sets the Reason field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Reason field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithReason(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.Reason = &value
+	return b
+}
+
+// WithMessage sets the Message field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Message field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithMessage(value string) *ClusterResourceSyncConditionApplyConfiguration {
+	b.Message = &value
+	return b
+}
+
+// WithLastTransitionTime sets the LastTransitionTime field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the LastTransitionTime field is set to the value of the last call.
+func (b *ClusterResourceSyncConditionApplyConfiguration) WithLastTransitionTime(value v1.Time) *ClusterResourceSyncConditionApplyConfiguration {
+	b.LastTransitionTime = &value
+	return b
+}
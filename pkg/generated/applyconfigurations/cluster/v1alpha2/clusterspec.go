@@ -0,0 +1,110 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// ClusterSpecApplyConfiguration represents an declarative configuration of the ClusterSpec type for use
+// with apply.
+type ClusterSpecApplyConfiguration struct {
+	Kubeconfig             []byte                                    `json:"kubeconfig,omitempty"`
+	APIServer              *string                                   `json:"apiserver,omitempty"`
+	TokenData              []byte                                    `json:"tokenData,omitempty"`
+	CAData                 []byte                                    `json:"caData,omitempty"`
+	CertData               []byte                                    `json:"certData,omitempty"`
+	KeyData                []byte                                    `json:"keyData,omitempty"`
+	SyncResources          []ClusterGroupResourcesApplyConfiguration `json:"syncResources,omitempty"`
+	SyncAllCustomResources *bool                                     `json:"syncAllCustomResources,omitempty"`
+	SyncResourcesRefName   *string                                   `json:"syncResourcesRefName,omitempty"`
+}
+
+// ClusterSpecApplyConfiguration constructs an declarative configuration of the ClusterSpec type for use with
+// apply.
+func ClusterSpec() *ClusterSpecApplyConfiguration {
+	return &ClusterSpecApplyConfiguration{}
+}
+
+// WithKubeconfig adds the given value to the Kubeconfig field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Kubeconfig field.
+func (b *ClusterSpecApplyConfiguration) WithKubeconfig(values ...byte) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		b.Kubeconfig = append(b.Kubeconfig, values[i])
+	}
+	return b
+}
+
+// WithAPIServer sets the APIServer field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the APIServer field is set to the value of the last call.
+func (b *ClusterSpecApplyConfiguration) WithAPIServer(value string) *ClusterSpecApplyConfiguration {
+	b.APIServer = &value
+	return b
+}
+
+// WithTokenData adds the given value to the TokenData field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the TokenData field.
+func (b *ClusterSpecApplyConfiguration) WithTokenData(values ...byte) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		b.TokenData = append(b.TokenData, values[i])
+	}
+	return b
+}
+
+// WithCAData adds the given value to the CAData field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the CAData field.
+func (b *ClusterSpecApplyConfiguration) WithCAData(values ...byte) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		b.CAData = append(b.CAData, values[i])
+	}
+	return b
+}
+
+// WithCertData adds the given value to the CertData field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the CertData field.
+func (b *ClusterSpecApplyConfiguration) WithCertData(values ...byte) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		b.CertData = append(b.CertData, values[i])
+	}
+	return b
+}
+
+// WithKeyData adds the given value to the KeyData field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the KeyData field.
+func (b *ClusterSpecApplyConfiguration) WithKeyData(values ...byte) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		b.KeyData = append(b.KeyData, values[i])
+	}
+	return b
+}
+
+// WithSyncResources adds the given value to the SyncResources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the SyncResources field.
+func (b *ClusterSpecApplyConfiguration) WithSyncResources(values ...*ClusterGroupResourcesApplyConfiguration) *ClusterSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithSyncResources")
+		}
+		b.SyncResources = append(b.SyncResources, *values[i])
+	}
+	return b
+}
+
+// WithSyncAllCustomResources sets the SyncAllCustomResources field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SyncAllCustomResources field is set to the value of the last call.
+func (b *ClusterSpecApplyConfiguration) WithSyncAllCustomResources(value bool) *ClusterSpecApplyConfiguration {
+	b.SyncAllCustomResources = &value
+	return b
+}
+
+// WithSyncResourcesRefName sets the SyncResourcesRefName field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the SyncResourcesRefName field is set to the value of the last call.
+func (b *ClusterSpecApplyConfiguration) WithSyncResourcesRefName(value string) *ClusterSpecApplyConfiguration {
+	b.SyncResourcesRefName = &value
+	return b
+}
@@ -0,0 +1,61 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ClusterStatusApplyConfiguration represents an declarative configuration of the ClusterStatus type for use
+// with apply.
+type ClusterStatusApplyConfiguration struct {
+	APIServer     *string                                         `json:"apiserver,omitempty"`
+	Version       *string                                         `json:"version,omitempty"`
+	Conditions    []v1.Condition                                  `json:"conditions,omitempty"`
+	SyncResources []ClusterGroupResourcesStatusApplyConfiguration `json:"syncResources,omitempty"`
+}
+
+// ClusterStatusApplyConfiguration constructs an declarative configuration of the ClusterStatus type for use with
+// apply.
+func ClusterStatus() *ClusterStatusApplyConfiguration {
+	return &ClusterStatusApplyConfiguration{}
+}
+
+// WithAPIServer sets the APIServer field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the APIServer field is set to the value of the last call.
+func (b *ClusterStatusApplyConfiguration) WithAPIServer(value string) *ClusterStatusApplyConfiguration {
+	b.APIServer = &value
+	return b
+}
+
+// WithVersion sets the Version field in the declarative configuration to the given value
+// and returns the receiver, so that objects can be built by chaining "With" function invocations.
+// If called multiple times, the Version field is set to the value of the last call.
+func (b *ClusterStatusApplyConfiguration) WithVersion(value string) *ClusterStatusApplyConfiguration {
+	b.Version = &value
+	return b
+}
+
+// WithConditions adds the given value to the Conditions field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the Conditions field.
+func (b *ClusterStatusApplyConfiguration) WithConditions(values ...v1.Condition) *ClusterStatusApplyConfiguration {
+	for i := range values {
+		b.Conditions = append(b.Conditions, values[i])
+	}
+	return b
+}
+
+// WithSyncResources adds the given value to the SyncResources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the SyncResources field.
+func (b *ClusterStatusApplyConfiguration) WithSyncResources(values ...*ClusterGroupResourcesStatusApplyConfiguration) *ClusterStatusApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithSyncResources")
+		}
+		b.SyncResources = append(b.SyncResources, *values[i])
+	}
+	return b
+}
@@ -0,0 +1,28 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package v1alpha2
+
+// ClusterSyncResourcesSpecApplyConfiguration represents an declarative configuration of the ClusterSyncResourcesSpec type for use
+// with apply.
+type ClusterSyncResourcesSpecApplyConfiguration struct {
+	SyncResources []ClusterGroupResourcesApplyConfiguration `json:"syncResources,omitempty"`
+}
+
+// ClusterSyncResourcesSpecApplyConfiguration constructs an declarative configuration of the ClusterSyncResourcesSpec type for use with
+// apply.
+func ClusterSyncResourcesSpec() *ClusterSyncResourcesSpecApplyConfiguration {
+	return &ClusterSyncResourcesSpecApplyConfiguration{}
+}
+
+// WithSyncResources adds the given value to the SyncResources field in the declarative configuration
+// and returns the receiver, so that objects can be build by chaining "With" function invocations.
+// If called multiple times, values provided by each call will be appended to the SyncResources field.
+func (b *ClusterSyncResourcesSpecApplyConfiguration) WithSyncResources(values ...*ClusterGroupResourcesApplyConfiguration) *ClusterSyncResourcesSpecApplyConfiguration {
+	for i := range values {
+		if values[i] == nil {
+			panic("nil value passed to WithSyncResources")
+		}
+		b.SyncResources = append(b.SyncResources, *values[i])
+	}
+	return b
+}
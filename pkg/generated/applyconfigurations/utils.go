@@ -0,0 +1,37 @@
+// Code generated by applyconfiguration-gen. DO NOT EDIT.
+
+package applyconfigurations
+
+import (
+	v1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	clusterv1alpha2 "github.com/clusterpedia-io/client-go/pkg/generated/applyconfigurations/cluster/v1alpha2"
+	schema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ForKind returns an apply configuration type for the given GroupVersionKind, or nil if no
+// apply configuration type exists for the given GroupVersionKind.
+func ForKind(kind schema.GroupVersionKind) interface{} {
+	switch kind {
+	// Group=cluster.clusterpedia.io, Version=v1alpha2
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterGroupResources"):
+		return &clusterv1alpha2.ClusterGroupResourcesApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterGroupResourcesStatus"):
+		return &clusterv1alpha2.ClusterGroupResourcesStatusApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterResourceStatus"):
+		return &clusterv1alpha2.ClusterResourceStatusApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterResourceSyncCondition"):
+		return &clusterv1alpha2.ClusterResourceSyncConditionApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterSpec"):
+		return &clusterv1alpha2.ClusterSpecApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterStatus"):
+		return &clusterv1alpha2.ClusterStatusApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterSyncResources"):
+		return &clusterv1alpha2.ClusterSyncResourcesApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("ClusterSyncResourcesSpec"):
+		return &clusterv1alpha2.ClusterSyncResourcesSpecApplyConfiguration{}
+	case v1alpha2.SchemeGroupVersion.WithKind("PediaCluster"):
+		return &clusterv1alpha2.PediaClusterApplyConfiguration{}
+
+	}
+	return nil
+}
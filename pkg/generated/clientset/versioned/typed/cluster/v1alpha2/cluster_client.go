@@ -10,6 +10,7 @@ import (
 
 type ClusterV1alpha2Interface interface {
 	RESTClient() rest.Interface
+	ClusterSyncResourcesGetter
 	PediaClustersGetter
 }
 
@@ -18,6 +19,10 @@ type ClusterV1alpha2Client struct {
 	restClient rest.Interface
 }
 
+func (c *ClusterV1alpha2Client) ClusterSyncResources() ClusterSyncResourcesInterface {
+	return newClusterSyncResources(c)
+}
+
 func (c *ClusterV1alpha2Client) PediaClusters() PediaClusterInterface {
 	return newPediaClusters(c)
 }
@@ -0,0 +1,181 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package v1alpha2
+
+import (
+	"context"
+	json "encoding/json"
+	"fmt"
+	"time"
+
+	v1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	clusterv1alpha2 "github.com/clusterpedia-io/client-go/pkg/generated/applyconfigurations/cluster/v1alpha2"
+	scheme "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/scheme"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	rest "k8s.io/client-go/rest"
+)
+
+// ClusterSyncResourcesGetter has a method to return a ClusterSyncResourcesInterface.
+// A group's client should implement this interface.
+type ClusterSyncResourcesGetter interface {
+	ClusterSyncResources() ClusterSyncResourcesInterface
+}
+
+// ClusterSyncResourcesInterface has methods to work with ClusterSyncResources resources.
+type ClusterSyncResourcesInterface interface {
+	Create(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.CreateOptions) (*v1alpha2.ClusterSyncResources, error)
+	Update(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.UpdateOptions) (*v1alpha2.ClusterSyncResources, error)
+	Delete(ctx context.Context, name string, opts v1.DeleteOptions) error
+	DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error
+	Get(ctx context.Context, name string, opts v1.GetOptions) (*v1alpha2.ClusterSyncResources, error)
+	List(ctx context.Context, opts v1.ListOptions) (*v1alpha2.ClusterSyncResourcesList, error)
+	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
+	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha2.ClusterSyncResources, err error)
+	Apply(ctx context.Context, clusterSyncResources *clusterv1alpha2.ClusterSyncResourcesApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.ClusterSyncResources, err error)
+	ClusterSyncResourcesExpansion
+}
+
+// clusterSyncResources implements ClusterSyncResourcesInterface
+type clusterSyncResources struct {
+	client rest.Interface
+}
+
+// newClusterSyncResources returns a ClusterSyncResources
+func newClusterSyncResources(c *ClusterV1alpha2Client) *clusterSyncResources {
+	return &clusterSyncResources{
+		client: c.RESTClient(),
+	}
+}
+
+// Get takes name of the clusterSyncResources, and returns the corresponding clusterSyncResources object, and an error if there is any.
+func (c *clusterSyncResources) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	result = &v1alpha2.ClusterSyncResources{}
+	err = c.client.Get().
+		Resource("clustersyncresources").
+		Name(name).
+		VersionedParams(&options, scheme.ParameterCodec).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// List takes label and field selectors, and returns the list of ClusterSyncResources that match those selectors.
+func (c *clusterSyncResources) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha2.ClusterSyncResourcesList, err error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	result = &v1alpha2.ClusterSyncResourcesList{}
+	err = c.client.Get().
+		Resource("clustersyncresources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Watch returns a watch.Interface that watches the requested clusterSyncResources.
+func (c *clusterSyncResources) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	var timeout time.Duration
+	if opts.TimeoutSeconds != nil {
+		timeout = time.Duration(*opts.TimeoutSeconds) * time.Second
+	}
+	opts.Watch = true
+	return c.client.Get().
+		Resource("clustersyncresources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Watch(ctx)
+}
+
+// Create takes the representation of a clusterSyncResources and creates it.  Returns the server's representation of the clusterSyncResources, and an error, if there is any.
+func (c *clusterSyncResources) Create(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.CreateOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	result = &v1alpha2.ClusterSyncResources{}
+	err = c.client.Post().
+		Resource("clustersyncresources").
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(clusterSyncResources).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Update takes the representation of a clusterSyncResources and updates it. Returns the server's representation of the clusterSyncResources, and an error, if there is any.
+func (c *clusterSyncResources) Update(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.UpdateOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	result = &v1alpha2.ClusterSyncResources{}
+	err = c.client.Put().
+		Resource("clustersyncresources").
+		Name(clusterSyncResources.Name).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(clusterSyncResources).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Delete takes name of the clusterSyncResources and deletes it. Returns an error if one occurs.
+func (c *clusterSyncResources) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	return c.client.Delete().
+		Resource("clustersyncresources").
+		Name(name).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *clusterSyncResources) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	var timeout time.Duration
+	if listOpts.TimeoutSeconds != nil {
+		timeout = time.Duration(*listOpts.TimeoutSeconds) * time.Second
+	}
+	return c.client.Delete().
+		Resource("clustersyncresources").
+		VersionedParams(&listOpts, scheme.ParameterCodec).
+		Timeout(timeout).
+		Body(&opts).
+		Do(ctx).
+		Error()
+}
+
+// Patch applies the patch and returns the patched clusterSyncResources.
+func (c *clusterSyncResources) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha2.ClusterSyncResources, err error) {
+	result = &v1alpha2.ClusterSyncResources{}
+	err = c.client.Patch(pt).
+		Resource("clustersyncresources").
+		Name(name).
+		SubResource(subresources...).
+		VersionedParams(&opts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied clusterSyncResources.
+func (c *clusterSyncResources) Apply(ctx context.Context, clusterSyncResources *clusterv1alpha2.ClusterSyncResourcesApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	if clusterSyncResources == nil {
+		return nil, fmt.Errorf("clusterSyncResources provided to Apply must not be nil")
+	}
+	patchOpts := opts.ToPatchOptions()
+	data, err := json.Marshal(clusterSyncResources)
+	if err != nil {
+		return nil, err
+	}
+	name := clusterSyncResources.Name
+	if name == nil {
+		return nil, fmt.Errorf("clusterSyncResources.Name must be provided to Apply")
+	}
+	result = &v1alpha2.ClusterSyncResources{}
+	err = c.client.Patch(types.ApplyPatchType).
+		Resource("clustersyncresources").
+		Name(*name).
+		VersionedParams(&patchOpts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
@@ -0,0 +1,129 @@
+// Code generated by client-gen. DO NOT EDIT.
+
+package fake
+
+import (
+	"context"
+	json "encoding/json"
+	"fmt"
+
+	v1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	clusterv1alpha2 "github.com/clusterpedia-io/client-go/pkg/generated/applyconfigurations/cluster/v1alpha2"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	labels "k8s.io/apimachinery/pkg/labels"
+	types "k8s.io/apimachinery/pkg/types"
+	watch "k8s.io/apimachinery/pkg/watch"
+	testing "k8s.io/client-go/testing"
+)
+
+// FakeClusterSyncResources implements ClusterSyncResourcesInterface
+type FakeClusterSyncResources struct {
+	Fake *FakeClusterV1alpha2
+}
+
+var clustersyncresourcesResource = v1alpha2.SchemeGroupVersion.WithResource("clustersyncresources")
+
+var clustersyncresourcesKind = v1alpha2.SchemeGroupVersion.WithKind("ClusterSyncResources")
+
+// Get takes name of the clusterSyncResources, and returns the corresponding clusterSyncResources object, and an error if there is any.
+func (c *FakeClusterSyncResources) Get(ctx context.Context, name string, options v1.GetOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootGetAction(clustersyncresourcesResource, name), &v1alpha2.ClusterSyncResources{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ClusterSyncResources), err
+}
+
+// List takes label and field selectors, and returns the list of ClusterSyncResources that match those selectors.
+func (c *FakeClusterSyncResources) List(ctx context.Context, opts v1.ListOptions) (result *v1alpha2.ClusterSyncResourcesList, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootListAction(clustersyncresourcesResource, clustersyncresourcesKind, opts), &v1alpha2.ClusterSyncResourcesList{})
+	if obj == nil {
+		return nil, err
+	}
+
+	label, _, _ := testing.ExtractFromListOptions(opts)
+	if label == nil {
+		label = labels.Everything()
+	}
+	list := &v1alpha2.ClusterSyncResourcesList{ListMeta: obj.(*v1alpha2.ClusterSyncResourcesList).ListMeta}
+	for _, item := range obj.(*v1alpha2.ClusterSyncResourcesList).Items {
+		if label.Matches(labels.Set(item.Labels)) {
+			list.Items = append(list.Items, item)
+		}
+	}
+	return list, err
+}
+
+// Watch returns a watch.Interface that watches the requested clusterSyncResources.
+func (c *FakeClusterSyncResources) Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error) {
+	return c.Fake.
+		InvokesWatch(testing.NewRootWatchAction(clustersyncresourcesResource, opts))
+}
+
+// Create takes the representation of a clusterSyncResources and creates it.  Returns the server's representation of the clusterSyncResources, and an error, if there is any.
+func (c *FakeClusterSyncResources) Create(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.CreateOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootCreateAction(clustersyncresourcesResource, clusterSyncResources), &v1alpha2.ClusterSyncResources{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ClusterSyncResources), err
+}
+
+// Update takes the representation of a clusterSyncResources and updates it. Returns the server's representation of the clusterSyncResources, and an error, if there is any.
+func (c *FakeClusterSyncResources) Update(ctx context.Context, clusterSyncResources *v1alpha2.ClusterSyncResources, opts v1.UpdateOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootUpdateAction(clustersyncresourcesResource, clusterSyncResources), &v1alpha2.ClusterSyncResources{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ClusterSyncResources), err
+}
+
+// Delete takes name of the clusterSyncResources and deletes it. Returns an error if one occurs.
+func (c *FakeClusterSyncResources) Delete(ctx context.Context, name string, opts v1.DeleteOptions) error {
+	_, err := c.Fake.
+		Invokes(testing.NewRootDeleteActionWithOptions(clustersyncresourcesResource, name, opts), &v1alpha2.ClusterSyncResources{})
+	return err
+}
+
+// DeleteCollection deletes a collection of objects.
+func (c *FakeClusterSyncResources) DeleteCollection(ctx context.Context, opts v1.DeleteOptions, listOpts v1.ListOptions) error {
+	action := testing.NewRootDeleteCollectionAction(clustersyncresourcesResource, listOpts)
+
+	_, err := c.Fake.Invokes(action, &v1alpha2.ClusterSyncResourcesList{})
+	return err
+}
+
+// Patch applies the patch and returns the patched clusterSyncResources.
+func (c *FakeClusterSyncResources) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha2.ClusterSyncResources, err error) {
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(clustersyncresourcesResource, name, pt, data, subresources...), &v1alpha2.ClusterSyncResources{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ClusterSyncResources), err
+}
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied clusterSyncResources.
+func (c *FakeClusterSyncResources) Apply(ctx context.Context, clusterSyncResources *clusterv1alpha2.ClusterSyncResourcesApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.ClusterSyncResources, err error) {
+	if clusterSyncResources == nil {
+		return nil, fmt.Errorf("clusterSyncResources provided to Apply must not be nil")
+	}
+	data, err := json.Marshal(clusterSyncResources)
+	if err != nil {
+		return nil, err
+	}
+	name := clusterSyncResources.Name
+	if name == nil {
+		return nil, fmt.Errorf("clusterSyncResources.Name must be provided to Apply")
+	}
+	obj, err := c.Fake.
+		Invokes(testing.NewRootPatchSubresourceAction(clustersyncresourcesResource, *name, types.ApplyPatchType, data), &v1alpha2.ClusterSyncResources{})
+	if obj == nil {
+		return nil, err
+	}
+	return obj.(*v1alpha2.ClusterSyncResources), err
+}
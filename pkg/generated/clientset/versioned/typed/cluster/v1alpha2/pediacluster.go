@@ -4,9 +4,12 @@ package v1alpha2
 
 import (
 	"context"
+	json "encoding/json"
+	"fmt"
 	"time"
 
 	v1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	clusterv1alpha2 "github.com/clusterpedia-io/client-go/pkg/generated/applyconfigurations/cluster/v1alpha2"
 	scheme "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/scheme"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	types "k8s.io/apimachinery/pkg/types"
@@ -31,6 +34,8 @@ type PediaClusterInterface interface {
 	List(ctx context.Context, opts v1.ListOptions) (*v1alpha2.PediaClusterList, error)
 	Watch(ctx context.Context, opts v1.ListOptions) (watch.Interface, error)
 	Patch(ctx context.Context, name string, pt types.PatchType, data []byte, opts v1.PatchOptions, subresources ...string) (result *v1alpha2.PediaCluster, err error)
+	Apply(ctx context.Context, pediaCluster *clusterv1alpha2.PediaClusterApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.PediaCluster, err error)
+	ApplyStatus(ctx context.Context, pediaCluster *clusterv1alpha2.PediaClusterApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.PediaCluster, err error)
 	PediaClusterExpansion
 }
 
@@ -166,3 +171,57 @@ func (c *pediaClusters) Patch(ctx context.Context, name string, pt types.PatchTy
 		Into(result)
 	return
 }
+
+// Apply takes the given apply declarative configuration, applies it and returns the applied pediaCluster.
+func (c *pediaClusters) Apply(ctx context.Context, pediaCluster *clusterv1alpha2.PediaClusterApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.PediaCluster, err error) {
+	if pediaCluster == nil {
+		return nil, fmt.Errorf("pediaCluster provided to Apply must not be nil")
+	}
+	patchOpts := opts.ToPatchOptions()
+	data, err := json.Marshal(pediaCluster)
+	if err != nil {
+		return nil, err
+	}
+	name := pediaCluster.Name
+	if name == nil {
+		return nil, fmt.Errorf("pediaCluster.Name must be provided to Apply")
+	}
+	result = &v1alpha2.PediaCluster{}
+	err = c.client.Patch(types.ApplyPatchType).
+		Resource("pediaclusters").
+		Name(*name).
+		VersionedParams(&patchOpts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
+
+// ApplyStatus was generated because the type contains a Status member.
+// Add a +genclient:noStatus comment above the type to avoid generating ApplyStatus().
+func (c *pediaClusters) ApplyStatus(ctx context.Context, pediaCluster *clusterv1alpha2.PediaClusterApplyConfiguration, opts v1.ApplyOptions) (result *v1alpha2.PediaCluster, err error) {
+	if pediaCluster == nil {
+		return nil, fmt.Errorf("pediaCluster provided to Apply must not be nil")
+	}
+	patchOpts := opts.ToPatchOptions()
+	data, err := json.Marshal(pediaCluster)
+	if err != nil {
+		return nil, err
+	}
+
+	name := pediaCluster.Name
+	if name == nil {
+		return nil, fmt.Errorf("pediaCluster.Name must be provided to Apply")
+	}
+
+	result = &v1alpha2.PediaCluster{}
+	err = c.client.Patch(types.ApplyPatchType).
+		Resource("pediaclusters").
+		Name(*name).
+		SubResource("status").
+		VersionedParams(&patchOpts, scheme.ParameterCodec).
+		Body(data).
+		Do(ctx).
+		Into(result)
+	return
+}
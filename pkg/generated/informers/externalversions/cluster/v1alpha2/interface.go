@@ -8,6 +8,8 @@ import (
 
 // Interface provides access to all the informers in this group version.
 type Interface interface {
+	// ClusterSyncResources returns a ClusterSyncResourcesInformer.
+	ClusterSyncResources() ClusterSyncResourcesInformer
 	// PediaClusters returns a PediaClusterInformer.
 	PediaClusters() PediaClusterInformer
 }
@@ -23,6 +25,11 @@ func New(f internalinterfaces.SharedInformerFactory, namespace string, tweakList
 	return &version{factory: f, namespace: namespace, tweakListOptions: tweakListOptions}
 }
 
+// ClusterSyncResources returns a ClusterSyncResourcesInformer.
+func (v *version) ClusterSyncResources() ClusterSyncResourcesInformer {
+	return &clusterSyncResourcesInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
+}
+
 // PediaClusters returns a PediaClusterInformer.
 func (v *version) PediaClusters() PediaClusterInformer {
 	return &pediaClusterInformer{factory: v.factory, tweakListOptions: v.tweakListOptions}
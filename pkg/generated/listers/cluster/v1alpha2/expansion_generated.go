@@ -2,6 +2,10 @@
 
 package v1alpha2
 
+// ClusterSyncResourcesListerExpansion allows custom methods to be added to
+// ClusterSyncResourcesLister.
+type ClusterSyncResourcesListerExpansion interface{}
+
 // PediaClusterListerExpansion allows custom methods to be added to
 // PediaClusterLister.
 type PediaClusterListerExpansion interface{}
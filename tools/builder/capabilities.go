@@ -0,0 +1,116 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package builder
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+// Capabilities reports the search features supported by clusterpedia,
+// it is implemented by tools/capabilities.
+type Capabilities interface {
+	// SupportsLabel reports whether the search label is supported.
+	SupportsLabel(label string) bool
+
+	// SupportsOrderBy reports whether the search can be ordered by field.
+	SupportsOrderBy(field string) bool
+}
+
+// UnsupportedError is returned by Validate for the search terms the server does not support.
+type UnsupportedError struct {
+	Terms []string
+}
+
+func (e *UnsupportedError) Error() string {
+	return fmt.Sprintf("search terms are not supported by clusterpedia: %s", strings.Join(e.Terms, ", "))
+}
+
+// Validate returns an UnsupportedError if the search of opts uses terms that
+// caps does not support. The terms are read from opts.Options(), so any
+// implementation of ListOptionsInterface can be validated.
+func Validate(opts ListOptionsInterface, caps Capabilities) error {
+	selector, err := labels.Parse(opts.Options().LabelSelector)
+	if err != nil {
+		return err
+	}
+
+	var terms []string
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		label := requirement.Key()
+		if label == constants.SearchLabelOrderBy {
+			for _, value := range requirement.Values().List() {
+				if !caps.SupportsOrderBy(orderByField(value)) {
+					terms = append(terms, label+"="+value)
+				}
+			}
+			continue
+		}
+		if !caps.SupportsLabel(label) {
+			terms = append(terms, label)
+		}
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+
+	sort.Strings(terms)
+	return &UnsupportedError{Terms: terms}
+}
+
+// Degrade returns a copy of opts built by ListOptionsBuilder without the search
+// terms that caps does not support, the search is still executed but may return
+// more items or in another order. opts is not modified. Other implementations
+// of ListOptionsInterface are returned unchanged.
+func Degrade(opts ListOptionsInterface, caps Capabilities) ListOptionsInterface {
+	original, ok := opts.(*listOptions)
+	if !ok {
+		return opts
+	}
+	o := original.deepCopy()
+
+	for label, values := range o.labels {
+		if label == constants.SearchLabelOrderBy {
+			var supported []string
+			for _, value := range values {
+				if caps.SupportsOrderBy(orderByField(value)) {
+					supported = append(supported, value)
+				}
+			}
+			values = supported
+		} else if !caps.SupportsLabel(label) {
+			values = nil
+		}
+
+		if len(values) == 0 {
+			delete(o.labels, label)
+		} else {
+			o.labels[label] = values
+		}
+	}
+	return o
+}
+
+func orderByField(value string) string {
+	return strings.TrimSuffix(value, constants.OrderByDesc)
+}
@@ -60,6 +60,24 @@ type listOptions struct {
 	fieldSelector map[string][]string
 }
 
+func (opts *listOptions) deepCopy() *listOptions {
+	out := &listOptions{
+		options:       *opts.options.DeepCopy(),
+		labels:        make(map[string][]string, len(opts.labels)),
+		fieldSelector: make(map[string][]string, len(opts.fieldSelector)),
+	}
+	for label, values := range opts.labels {
+		out.labels[label] = append([]string(nil), values...)
+	}
+	for field, values := range opts.fieldSelector {
+		out.fieldSelector[field] = append([]string(nil), values...)
+	}
+	if opts.labelSelector != nil {
+		out.labelSelector = opts.labelSelector.DeepCopySelector()
+	}
+	return out
+}
+
 func ListOptionsBuilder() ListOptionsInterface {
 	return &listOptions{
 		options:       metav1.ListOptions{},
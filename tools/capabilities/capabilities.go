@@ -0,0 +1,250 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capabilities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilversion "k8s.io/apimachinery/pkg/util/version"
+	"k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/client"
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+)
+
+// Feature is a search feature that depends on the version and the storage
+// layer of clusterpedia.
+type Feature string
+
+const (
+	FeatureFuzzyName      Feature = "FuzzyName"
+	FeatureOwnerSearch    Feature = "OwnerSearch"
+	FeatureRemainingCount Feature = "RemainingCount"
+	FeatureWatch          Feature = "Watch"
+	FeatureOrderBy        Feature = "OrderBy"
+)
+
+// DefaultOrderByFields are the fields probed for FeatureOrderBy.
+var DefaultOrderByFields = []string{"cluster", "namespace", "name", "created_at", "resource_version"}
+
+var featureLabels = map[string]Feature{
+	constants.SearchLabelFuzzyName:          FeatureFuzzyName,
+	constants.SearchLabelOwnerUID:           FeatureOwnerSearch,
+	constants.SearchLabelOwnerName:          FeatureOwnerSearch,
+	constants.SearchLabelOwnerSeniority:     FeatureOwnerSearch,
+	constants.SearchLabelOwnerGroupResource: FeatureOwnerSearch,
+	constants.SearchLabelWithRemainingCount: FeatureRemainingCount,
+}
+
+var _ builder.Capabilities = &Capabilities{}
+
+// Capabilities are the version and the search features of clusterpedia.
+type Capabilities struct {
+	// Version is nil if the server does not report its version.
+	Version *version.Info
+
+	Features      map[Feature]bool
+	OrderByFields []string
+}
+
+// Supports reports whether the feature is supported.
+func (c *Capabilities) Supports(feature Feature) bool {
+	return c.Features[feature]
+}
+
+// SupportsLabel reports whether the search label is supported, the labels
+// not bound to a feature are always supported.
+func (c *Capabilities) SupportsLabel(label string) bool {
+	feature, ok := featureLabels[label]
+	return !ok || c.Supports(feature)
+}
+
+// SupportsOrderBy reports whether the search can be ordered by field.
+func (c *Capabilities) SupportsOrderBy(field string) bool {
+	for _, f := range c.OrderByFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// AtLeast reports whether the server version is at least v, such as "v0.7.0".
+// It is false if the server version is unknown.
+func (c *Capabilities) AtLeast(v string) bool {
+	if c.Version == nil {
+		return false
+	}
+	serverVersion, err := utilversion.ParseGeneric(c.Version.GitVersion)
+	if err != nil {
+		return false
+	}
+	return serverVersion.AtLeast(utilversion.MustParseGeneric(v))
+}
+
+type Options struct {
+	// Resource is the resource searched by the probes, defaults to the first
+	// listable resource synchronized by clusterpedia.
+	Resource schema.GroupVersionResource
+
+	// OrderByFields are the fields probed for FeatureOrderBy, defaults to DefaultOrderByFields.
+	OrderByFields []string
+}
+
+// probeName is a name no object is expected to have, the searches filtered by
+// it must return no items.
+const probeName = "clusterpedia-capability-probe-7f3c9a"
+
+// Detect detects the version and the search features of clusterpedia. Every
+// search feature is probed with a search of a single item, a feature is
+// unsupported if the server rejects its search. The filters are probed with a
+// name no object has, so a server ignoring the unknown labels, which returns
+// the unfiltered items, does not report them as supported.
+func Detect(ctx context.Context, cfg *rest.Config, opts Options) (*Capabilities, error) {
+	pediaConfig, err := client.ConfigFor(cfg)
+	if err != nil {
+		return nil, err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(pediaConfig)
+	if err != nil {
+		return nil, err
+	}
+	dynamicClient, err := dynamic.NewForConfig(pediaConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	caps := &Capabilities{Features: make(map[Feature]bool)}
+	if info, err := dc.ServerVersion(); err == nil {
+		caps.Version = info
+	}
+
+	resources, err := dc.ServerPreferredResources()
+	if len(resources) == 0 && err != nil {
+		return nil, fmt.Errorf("failed to discover the resources of clusterpedia: %w", err)
+	}
+	resource := opts.Resource
+	caps.Features[FeatureWatch] = false
+	for _, list := range resources {
+		gv, err := schema.ParseGroupVersion(list.GroupVersion)
+		if err != nil {
+			continue
+		}
+		for _, r := range list.APIResources {
+			if hasVerb(r.Verbs, "watch") {
+				caps.Features[FeatureWatch] = true
+			}
+			if resource.Empty() && hasVerb(r.Verbs, "list") {
+				resource = gv.WithResource(r.Name)
+			}
+		}
+	}
+	if resource.Empty() {
+		return nil, errors.New("no resources are synchronized by clusterpedia to probe the search features")
+	}
+
+	p := &prober{client: dynamicClient.Resource(resource)}
+	if caps.Features[FeatureFuzzyName], err = p.probeFilter(ctx, builder.ListOptionsBuilder().FuzzyNames(probeName)); err != nil {
+		return nil, err
+	}
+	if caps.Features[FeatureOwnerSearch], err = p.probeFilter(ctx, builder.ListOptionsBuilder().OwnerName(probeName)); err != nil {
+		return nil, err
+	}
+	if caps.Features[FeatureRemainingCount], err = p.probeRemainingCount(ctx); err != nil {
+		return nil, err
+	}
+
+	fields := opts.OrderByFields
+	if len(fields) == 0 {
+		fields = DefaultOrderByFields
+	}
+	for _, field := range fields {
+		supported, err := p.probe(ctx, builder.ListOptionsBuilder().OrderBy(field))
+		if err != nil {
+			return nil, err
+		}
+		if supported {
+			caps.OrderByFields = append(caps.OrderByFields, field)
+		}
+	}
+	sort.Strings(caps.OrderByFields)
+	caps.Features[FeatureOrderBy] = len(caps.OrderByFields) != 0
+	return caps, nil
+}
+
+type prober struct {
+	client dynamic.NamespaceableResourceInterface
+}
+
+// probe returns false if the search is rejected, and an error if the server
+// fails for another reason, such as being unreachable, forbidding the search
+// or failing internally.
+func (p *prober) probe(ctx context.Context, query builder.ListOptionsInterface) (bool, error) {
+	_, err := p.client.List(ctx, query.Limit(1).Options())
+	return supported(err)
+}
+
+// probeFilter returns true if the search filtered by probeName is accepted and
+// returns no items. It can not tell an ignored filter apart if the resource has
+// no objects at all.
+func (p *prober) probeFilter(ctx context.Context, query builder.ListOptionsInterface) (bool, error) {
+	list, err := p.client.List(ctx, query.Limit(1).Options())
+	if ok, err := supported(err); !ok || err != nil {
+		return ok, err
+	}
+	return len(list.Items) == 0, nil
+}
+
+func (p *prober) probeRemainingCount(ctx context.Context) (bool, error) {
+	list, err := p.client.List(ctx, builder.ListOptionsBuilder().RemainingCount().Limit(1).Options())
+	if ok, err := supported(err); !ok || err != nil {
+		return ok, err
+	}
+	return list.GetRemainingItemCount() != nil, nil
+}
+
+// supported returns false for the errors of a rejected search: NotFound,
+// BadRequest and MethodNotSupported. The other errors are returned, so that
+// a failing server is not mistaken for a missing feature.
+func supported(err error) (bool, error) {
+	switch {
+	case err == nil:
+		return true, nil
+	case apierrors.IsNotFound(err), apierrors.IsBadRequest(err), apierrors.IsMethodNotSupported(err):
+		return false, nil
+	}
+	return false, err
+}
+
+func hasVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package capabilities
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+)
+
+// pediaServer serves a storage layer with a single pod and without fuzzy name
+// search, ordering only by cluster and name.
+func pediaServer(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	write := func(code int, body interface{}) {
+		w.WriteHeader(code)
+		_ = json.NewEncoder(w).Encode(body)
+	}
+	badRequest := map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "BadRequest", "code": 400}
+
+	switch strings.TrimPrefix(r.URL.Path, constants.ClusterPediaAPIPath) {
+	case "/version":
+		write(http.StatusOK, map[string]interface{}{"gitVersion": "v0.7.2"})
+	case "/api":
+		write(http.StatusOK, map[string]interface{}{"kind": "APIVersions", "versions": []string{"v1"}})
+	case "/apis":
+		write(http.StatusOK, map[string]interface{}{"kind": "APIGroupList", "apiVersion": "v1", "groups": []interface{}{}})
+	case "/api/v1":
+		write(http.StatusOK, map[string]interface{}{"kind": "APIResourceList", "groupVersion": "v1", "resources": []interface{}{
+			map[string]interface{}{"name": "pods", "namespaced": true, "kind": "Pod", "verbs": []string{"get", "list"}},
+		}})
+	case "/api/v1/pods":
+		selector := r.URL.Query().Get("labelSelector")
+		switch {
+		case strings.Contains(selector, constants.SearchLabelFuzzyName):
+			write(http.StatusBadRequest, badRequest)
+		case strings.Contains(selector, constants.SearchLabelOrderBy) &&
+			selector != constants.SearchLabelOrderBy+"=name" && selector != constants.SearchLabelOrderBy+"=cluster":
+			write(http.StatusBadRequest, badRequest)
+		case strings.Contains(selector, constants.SearchLabelWithRemainingCount):
+			write(http.StatusOK, map[string]interface{}{"kind": "PodList", "apiVersion": "v1", "metadata": map[string]interface{}{"remainingItemCount": 3}, "items": []interface{}{}})
+		case strings.Contains(selector, constants.SearchLabelOwnerName):
+			write(http.StatusOK, map[string]interface{}{"kind": "PodList", "apiVersion": "v1", "metadata": map[string]interface{}{}, "items": []interface{}{}})
+		default:
+			write(http.StatusOK, podList)
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+var podList = map[string]interface{}{"kind": "PodList", "apiVersion": "v1", "metadata": map[string]interface{}{}, "items": []interface{}{
+	map[string]interface{}{"metadata": map[string]interface{}{"namespace": "default", "name": "pod-a"}},
+}}
+
+func TestDetect(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(pediaServer))
+	defer server.Close()
+
+	caps, err := Detect(context.TODO(), &rest.Config{Host: server.URL}, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	expect := map[Feature]bool{
+		FeatureFuzzyName:      false,
+		FeatureOwnerSearch:    true,
+		FeatureRemainingCount: true,
+		FeatureWatch:          false,
+		FeatureOrderBy:        true,
+	}
+	if !reflect.DeepEqual(caps.Features, expect) {
+		t.Errorf("Unexpect features: %v, expect: %v", caps.Features, expect)
+	}
+	if !reflect.DeepEqual(caps.OrderByFields, []string{"cluster", "name"}) {
+		t.Errorf("Unexpect orderby fields: %v", caps.OrderByFields)
+	}
+	if !caps.AtLeast("v0.7.0") || caps.AtLeast("v0.8.0") {
+		t.Errorf("Unexpect version negotiation of %s", caps.Version.GitVersion)
+	}
+
+	query := builder.ListOptionsBuilder().FuzzyNames("nginx").OrderBy("name").OrderBy("created_at", true)
+	err = builder.Validate(query, caps)
+	if unsupported, ok := err.(*builder.UnsupportedError); !ok || len(unsupported.Terms) != 2 {
+		t.Errorf("Unexpect validate error: %v", err)
+	}
+
+	degraded := builder.Degrade(query, caps)
+	if selector := degraded.Options().LabelSelector; selector != "search.clusterpedia.io/orderby=name" {
+		t.Errorf("Unexpect degraded selector: %s", selector)
+	}
+	if err := builder.Validate(degraded, caps); err != nil {
+		t.Errorf("Unexpect error after degrade: %v", err)
+	}
+	if err := builder.Validate(query, caps); err == nil {
+		t.Errorf("Unexpect change of the degraded query")
+	}
+}
+
+func TestDetectServerError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Query().Get("labelSelector"), constants.SearchLabelFuzzyName) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "InternalError", "code": 500})
+			return
+		}
+		pediaServer(w, r)
+	}))
+	defer server.Close()
+
+	if _, err := Detect(context.TODO(), &rest.Config{Host: server.URL}, Options{}); err == nil {
+		t.Errorf("Unexpect detection with a failing server, expect error")
+	}
+}
+
+func TestDetectIgnoredLabels(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// the owner and fuzzy name labels are accepted but do not filter
+		if selector := r.URL.Query().Get("labelSelector"); strings.Contains(selector, constants.SearchLabelFuzzyName) ||
+			strings.Contains(selector, constants.SearchLabelOwnerName) {
+			w.Header().Set("Content-Type", "application/json")
+			_ = json.NewEncoder(w).Encode(podList)
+			return
+		}
+		pediaServer(w, r)
+	}))
+	defer server.Close()
+
+	caps, err := Detect(context.TODO(), &rest.Config{Host: server.URL}, Options{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if caps.Supports(FeatureFuzzyName) || caps.Supports(FeatureOwnerSearch) {
+		t.Errorf("Unexpect features: %v, expect the ignored labels are not supported", caps.Features)
+	}
+}
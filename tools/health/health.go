@@ -0,0 +1,266 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/client"
+)
+
+// APIServiceName is the APIService registering clusterpedia apiserver.
+const APIServiceName = "v1beta1.clusterpedia.io"
+
+// DiscoveryTTL is how long Checker and Handler reuse a successful resources
+// discovery, the discovery of every group of clusterpedia is too expensive
+// to run on every probe.
+const DiscoveryTTL = time.Minute
+
+// the checks of a Report
+const (
+	CheckAPIService = "APIService"
+	CheckDiscovery  = "Discovery"
+	CheckPermission = "Permission"
+)
+
+var apiServiceResource = schema.GroupVersionResource{Group: "apiregistration.k8s.io", Version: "v1", Resource: "apiservices"}
+
+// Result is the result of a single check.
+type Result struct {
+	Name    string          `json:"name"`
+	Healthy bool            `json:"healthy"`
+	Reason  string          `json:"reason,omitempty"`
+	Message string          `json:"message,omitempty"`
+	Latency metav1.Duration `json:"latency"`
+}
+
+// Report is the diagnostic of the connectivity to clusterpedia apiserver.
+type Report struct {
+	Healthy bool     `json:"healthy"`
+	Checks  []Result `json:"checks"`
+}
+
+// Err returns nil if all checks passed, or an error describing the failed checks.
+func (r *Report) Err() error {
+	if r.Healthy {
+		return nil
+	}
+
+	var msgs []string
+	for _, check := range r.Checks {
+		if !check.Healthy {
+			msgs = append(msgs, fmt.Sprintf("%s: %s: %s", check.Name, check.Reason, check.Message))
+		}
+	}
+	return errors.New("clusterpedia is not ready: " + strings.Join(msgs, "; "))
+}
+
+// Check verifies that the clusterpedia APIService is registered and Available,
+// that the resources discovery of clusterpedia responds and that the user of
+// cfg is allowed to search. All checks are run even if some fail.
+func Check(ctx context.Context, cfg *rest.Config) *Report {
+	return check(ctx, cfg, checkDiscovery)
+}
+
+type checkFunc func(ctx context.Context, cfg *rest.Config) (reason string, err error)
+
+func check(ctx context.Context, cfg *rest.Config, discovery checkFunc) *Report {
+	report := &Report{Healthy: true}
+	run := func(name string, check checkFunc) {
+		start := time.Now()
+		reason, err := check(ctx, cfg)
+		result := Result{Name: name, Healthy: err == nil, Latency: metav1.Duration{Duration: time.Since(start)}}
+		if err != nil {
+			result.Reason, result.Message = reason, err.Error()
+			report.Healthy = false
+		}
+		report.Checks = append(report.Checks, result)
+	}
+
+	run(CheckAPIService, checkAPIService)
+	run(CheckDiscovery, discovery)
+	run(CheckPermission, checkPermission)
+	return report
+}
+
+func checkAPIService(ctx context.Context, cfg *rest.Config) (string, error) {
+	dc, err := dynamic.NewForConfig(cfg)
+	if err != nil {
+		return "InvalidConfig", err
+	}
+
+	apiService, err := dc.Resource(apiServiceResource).Get(ctx, APIServiceName, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return "NotRegistered", fmt.Errorf("APIService %s is not registered", APIServiceName)
+		}
+		return reasonOf(err), err
+	}
+
+	conditions, _, _ := unstructured.NestedSlice(apiService.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if !ok || condition["type"] != "Available" {
+			continue
+		}
+		if condition["status"] == string(metav1.ConditionTrue) {
+			return "", nil
+		}
+		reason, _ := condition["reason"].(string)
+		message, _ := condition["message"].(string)
+		return "Unavailable", fmt.Errorf("APIService %s is not available: %s: %s", APIServiceName, reason, message)
+	}
+	return "Unavailable", fmt.Errorf("APIService %s has no Available condition", APIServiceName)
+}
+
+func checkDiscovery(ctx context.Context, cfg *rest.Config) (string, error) {
+	pediaConfig, err := client.ConfigFor(cfg)
+	if err != nil {
+		return "InvalidConfig", err
+	}
+	dc, err := discovery.NewDiscoveryClientForConfig(pediaConfig)
+	if err != nil {
+		return "InvalidConfig", err
+	}
+
+	// the groups always include the legacy group once /api responds,
+	// so the synchronized resources are counted instead
+	lists, err := dc.ServerPreferredResources()
+	if err != nil && !discovery.IsGroupDiscoveryFailedError(err) {
+		return reasonOf(err), fmt.Errorf("resources discovery failed: %w", err)
+	}
+	var resources int
+	for _, list := range lists {
+		resources += len(list.APIResources)
+	}
+	if resources == 0 {
+		if err != nil {
+			return reasonOf(err), fmt.Errorf("resources discovery failed: %w", err)
+		}
+		return "NoResources", errors.New("resources discovery returned no resources, no resources are synchronized")
+	}
+	return "", nil
+}
+
+func checkPermission(ctx context.Context, cfg *rest.Config) (string, error) {
+	cs, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return "InvalidConfig", err
+	}
+
+	review, err := cs.AuthorizationV1().SelfSubjectAccessReviews().Create(ctx, &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Group:    "clusterpedia.io",
+				Resource: "resources",
+				Verb:     "list",
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return reasonOf(err), err
+	}
+	if !review.Status.Allowed {
+		message := "user is not allowed to list clusterpedia.io resources"
+		if review.Status.Reason != "" {
+			message += ": " + review.Status.Reason
+		}
+		return "Forbidden", errors.New(message)
+	}
+	return "", nil
+}
+
+func reasonOf(err error) string {
+	if reason := apierrors.ReasonForError(err); reason != metav1.StatusReasonUnknown {
+		return string(reason)
+	}
+	return "Unreachable"
+}
+
+// cachedDiscovery skips the resources discovery for DiscoveryTTL after it
+// succeeded, the failures are checked again on the next probe.
+type cachedDiscovery struct {
+	lock         sync.Mutex
+	discoveredAt time.Time
+	now          func() time.Time
+}
+
+func newCachedDiscovery() *cachedDiscovery {
+	return &cachedDiscovery{now: time.Now}
+}
+
+func (c *cachedDiscovery) check(ctx context.Context, cfg *rest.Config) (string, error) {
+	c.lock.Lock()
+	fresh := !c.discoveredAt.IsZero() && c.now().Sub(c.discoveredAt) < DiscoveryTTL
+	c.lock.Unlock()
+	if fresh {
+		return "", nil
+	}
+
+	reason, err := checkDiscovery(ctx, cfg)
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err != nil {
+		c.discoveredAt = time.Time{}
+	} else {
+		c.discoveredAt = c.now()
+	}
+	return reason, err
+}
+
+// Checker returns a check of the readiness of clusterpedia, which can be added
+// to a controller-runtime manager with AddReadyzCheck. The resources discovery
+// is reused for DiscoveryTTL once it succeeds.
+func Checker(cfg *rest.Config) func(req *http.Request) error {
+	discovery := newCachedDiscovery()
+	return func(req *http.Request) error {
+		return check(req.Context(), cfg, discovery.check).Err()
+	}
+}
+
+// Handler serves the Report as JSON, with 200 if all checks passed or 503 otherwise.
+// The resources discovery is reused for DiscoveryTTL once it succeeds.
+func Handler(cfg *rest.Config) http.Handler {
+	discovery := newCachedDiscovery()
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := check(req.Context(), cfg, discovery.check)
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		if !report.Healthy {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		_ = json.NewEncoder(w).Encode(report)
+	})
+}
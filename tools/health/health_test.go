@@ -0,0 +1,197 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+)
+
+type apiserver struct {
+	available bool
+	allowed   bool
+	resources bool
+
+	lock        sync.Mutex
+	discoveries int
+}
+
+func (s *apiserver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path == "/apis/clusterpedia.io/v1beta1/resources/api" {
+		s.lock.Lock()
+		s.discoveries++
+		s.lock.Unlock()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	var body interface{}
+	switch r.URL.Path {
+	case "/apis/apiregistration.k8s.io/v1/apiservices/" + APIServiceName:
+		status := "False"
+		if s.available {
+			status = "True"
+		}
+		body = map[string]interface{}{
+			"apiVersion": "apiregistration.k8s.io/v1",
+			"kind":       "APIService",
+			"metadata":   map[string]interface{}{"name": APIServiceName},
+			"status": map[string]interface{}{"conditions": []interface{}{
+				map[string]interface{}{"type": "Available", "status": status, "reason": "FailedDiscoveryCheck", "message": "no response"},
+			}},
+		}
+	case "/apis/clusterpedia.io/v1beta1/resources/api":
+		body = map[string]interface{}{"kind": "APIVersions", "versions": []string{"v1"}}
+	case "/apis/clusterpedia.io/v1beta1/resources/api/v1":
+		s.lock.Lock()
+		synced := s.resources
+		s.lock.Unlock()
+		resources := []interface{}{}
+		if synced {
+			resources = append(resources, map[string]interface{}{"name": "pods", "namespaced": true, "kind": "Pod", "verbs": []string{"get", "list"}})
+		}
+		body = map[string]interface{}{"kind": "APIResourceList", "apiVersion": "v1", "groupVersion": "v1", "resources": resources}
+	case "/apis/clusterpedia.io/v1beta1/resources/apis":
+		body = map[string]interface{}{"kind": "APIGroupList", "apiVersion": "v1", "groups": []interface{}{}}
+	case "/apis/authorization.k8s.io/v1/selfsubjectaccessreviews":
+		body = map[string]interface{}{
+			"apiVersion": "authorization.k8s.io/v1",
+			"kind":       "SelfSubjectAccessReview",
+			"status":     map[string]interface{}{"allowed": s.allowed},
+		}
+	default:
+		w.WriteHeader(http.StatusNotFound)
+		body = map[string]interface{}{"kind": "Status", "apiVersion": "v1", "status": "Failure", "reason": "NotFound", "code": 404}
+	}
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name      string
+		apiserver *apiserver
+		failed    map[string]string
+	}{
+		{
+			name:      "ready",
+			apiserver: &apiserver{available: true, allowed: true, resources: true},
+			failed:    map[string]string{},
+		},
+		{
+			name:      "unavailable and forbidden",
+			apiserver: &apiserver{resources: true},
+			failed:    map[string]string{CheckAPIService: "Unavailable", CheckPermission: "Forbidden"},
+		},
+		{
+			name:      "no resources",
+			apiserver: &apiserver{available: true, allowed: true},
+			failed:    map[string]string{CheckDiscovery: "NoResources"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(test.apiserver)
+			defer server.Close()
+
+			report := Check(context.TODO(), &rest.Config{Host: server.URL})
+			if report.Healthy != (len(test.failed) == 0) || (report.Err() == nil) != report.Healthy {
+				t.Errorf("Unexpect report: %+v", report)
+			}
+			if len(report.Checks) != 3 {
+				t.Fatalf("Unexpect checks: %d, expect: %d", len(report.Checks), 3)
+			}
+			for _, check := range report.Checks {
+				reason, failed := test.failed[check.Name]
+				if check.Healthy == failed || check.Reason != reason {
+					t.Errorf("Unexpect result of %s: %+v", check.Name, check)
+				}
+			}
+		})
+	}
+}
+
+func TestHandler(t *testing.T) {
+	server := httptest.NewServer(&apiserver{allowed: true, resources: true})
+	defer server.Close()
+
+	recorder := httptest.NewRecorder()
+	Handler(&rest.Config{Host: server.URL}).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	if recorder.Code != http.StatusServiceUnavailable {
+		t.Errorf("Unexpect code: %d, expect: %d", recorder.Code, http.StatusServiceUnavailable)
+	}
+
+	var report Report
+	if err := json.NewDecoder(recorder.Body).Decode(&report); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if report.Healthy || report.Checks[0].Reason != "Unavailable" {
+		t.Errorf("Unexpect report: %+v", report)
+	}
+}
+
+func TestCheckerCachesDiscovery(t *testing.T) {
+	apiserver := &apiserver{available: true, allowed: true}
+	server := httptest.NewServer(apiserver)
+	defer server.Close()
+
+	now := time.Now()
+	discovery := newCachedDiscovery()
+	discovery.now = func() time.Time { return now }
+	probe := func() *Report {
+		return check(context.TODO(), &rest.Config{Host: server.URL}, discovery.check)
+	}
+	discoveries := func() int {
+		apiserver.lock.Lock()
+		defer apiserver.lock.Unlock()
+		return apiserver.discoveries
+	}
+
+	// the failures are not cached
+	for i := 0; i < 2; i++ {
+		if report := probe(); report.Healthy {
+			t.Errorf("Unexpect healthy report without resources")
+		}
+	}
+	if got := discoveries(); got != 2 {
+		t.Errorf("Unexpect discoveries: %d, expect: %d", got, 2)
+	}
+
+	apiserver.lock.Lock()
+	apiserver.resources = true
+	apiserver.lock.Unlock()
+	for i := 0; i < 3; i++ {
+		if err := probe().Err(); err != nil {
+			t.Errorf("Unexpect error: %v", err)
+		}
+	}
+	if got := discoveries(); got != 3 {
+		t.Errorf("Unexpect discoveries: %d, expect the successful discovery is reused", got)
+	}
+
+	now = now.Add(DiscoveryTTL)
+	probe()
+	if got := discoveries(); got != 4 {
+		t.Errorf("Unexpect discoveries: %d, expect the discovery is run again after the TTL", got)
+	}
+}
@@ -0,0 +1,167 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"io"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// AnnotationCredentials marks the PediaClusters of a bundle whose credentials
+// are redacted or encrypted, it is removed on import.
+const AnnotationCredentials = "inventory.clusterpedia.io/credentials"
+
+// CredentialMode is how the credentials of the PediaClusters are exported.
+type CredentialMode string
+
+const (
+	// CredentialsPlain exports the credentials as they are.
+	CredentialsPlain CredentialMode = "plain"
+
+	// CredentialsRedacted removes the token, the client certificate and key, and
+	// the secrets of the users of the kubeconfig. The apiserver and the CA are kept.
+	CredentialsRedacted CredentialMode = "redacted"
+
+	// CredentialsEncrypted encrypts the kubeconfig, the token, the client
+	// certificate and key with AES-GCM.
+	CredentialsEncrypted CredentialMode = "encrypted"
+)
+
+// credentialSealer returns the function exporting the credentials of a
+// PediaCluster in mode.
+func credentialSealer(mode CredentialMode, key []byte) (func(*clusterv1alpha2.PediaCluster) error, error) {
+	switch mode {
+	case CredentialsPlain:
+		return func(*clusterv1alpha2.PediaCluster) error { return nil }, nil
+	case CredentialsRedacted:
+		return func(cluster *clusterv1alpha2.PediaCluster) error {
+			if err := redact(&cluster.Spec); err != nil {
+				return err
+			}
+			markCredentials(cluster, CredentialsRedacted)
+			return nil
+		}, nil
+	case CredentialsEncrypted:
+		aead, err := newAEAD(key)
+		if err != nil {
+			return nil, err
+		}
+		return func(cluster *clusterv1alpha2.PediaCluster) error {
+			for _, field := range credentialFields(&cluster.Spec) {
+				if len(*field) == 0 {
+					continue
+				}
+				nonce := make([]byte, aead.NonceSize())
+				if _, err := io.ReadFull(rand.Reader, nonce); err != nil {
+					return err
+				}
+				*field = aead.Seal(nonce, nonce, *field, []byte(cluster.Name))
+			}
+			markCredentials(cluster, CredentialsEncrypted)
+			return nil
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported credential mode %q", mode)
+	}
+}
+
+// openCredentials restores the credentials of a PediaCluster of a bundle and
+// removes the mark, it returns true if the credentials are redacted.
+func openCredentials(cluster *clusterv1alpha2.PediaCluster, key []byte) (bool, error) {
+	mode := CredentialMode(cluster.Annotations[AnnotationCredentials])
+	delete(cluster.Annotations, AnnotationCredentials)
+	if len(cluster.Annotations) == 0 {
+		cluster.Annotations = nil
+	}
+
+	switch mode {
+	case "", CredentialsPlain:
+		return false, nil
+	case CredentialsRedacted:
+		return true, nil
+	case CredentialsEncrypted:
+		if len(key) == 0 {
+			return false, errors.New("credentials are encrypted, but no key is provided")
+		}
+		aead, err := newAEAD(key)
+		if err != nil {
+			return false, err
+		}
+		for _, field := range credentialFields(&cluster.Spec) {
+			if len(*field) == 0 {
+				continue
+			}
+			if len(*field) < aead.NonceSize() {
+				return false, errors.New("encrypted credentials are truncated")
+			}
+			nonce, ciphertext := (*field)[:aead.NonceSize()], (*field)[aead.NonceSize():]
+			if *field, err = aead.Open(nil, nonce, ciphertext, []byte(cluster.Name)); err != nil {
+				return false, fmt.Errorf("failed to decrypt the credentials: %w", err)
+			}
+		}
+		return false, nil
+	default:
+		return false, fmt.Errorf("unsupported credential mode %q", mode)
+	}
+}
+
+func markCredentials(cluster *clusterv1alpha2.PediaCluster, mode CredentialMode) {
+	if cluster.Annotations == nil {
+		cluster.Annotations = make(map[string]string)
+	}
+	cluster.Annotations[AnnotationCredentials] = string(mode)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+func credentialFields(spec *clusterv1alpha2.ClusterSpec) []*[]byte {
+	return []*[]byte{&spec.Kubeconfig, &spec.TokenData, &spec.CertData, &spec.KeyData}
+}
+
+func redact(spec *clusterv1alpha2.ClusterSpec) error {
+	spec.TokenData, spec.CertData, spec.KeyData = nil, nil, nil
+	if len(spec.Kubeconfig) == 0 {
+		return nil
+	}
+
+	config, err := clientcmd.Load(spec.Kubeconfig)
+	if err != nil {
+		return err
+	}
+	for _, authInfo := range config.AuthInfos {
+		authInfo.Token, authInfo.TokenFile = "", ""
+		authInfo.ClientCertificate, authInfo.ClientCertificateData = "", nil
+		authInfo.ClientKey, authInfo.ClientKeyData = "", nil
+		authInfo.Username, authInfo.Password = "", ""
+		authInfo.AuthProvider, authInfo.Exec = nil, nil
+	}
+	spec.Kubeconfig, err = clientcmd.Write(*config)
+	return err
+}
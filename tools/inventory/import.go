@@ -0,0 +1,310 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"github.com/google/go-cmp/cmp"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	clusterv1alpha2client "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/typed/cluster/v1alpha2"
+)
+
+// ConflictPolicy is how a PediaCluster of the bundle that exists with a
+// different spec, labels or annotations is imported.
+type ConflictPolicy string
+
+const (
+	// ConflictFail fails the import before anything is written.
+	ConflictFail ConflictPolicy = "Fail"
+
+	// ConflictSkip keeps the existing PediaCluster.
+	ConflictSkip ConflictPolicy = "Skip"
+
+	// ConflictOverwrite replaces the spec of the existing PediaCluster, the
+	// labels and annotations of the bundle are merged into the existing ones.
+	ConflictOverwrite ConflictPolicy = "Overwrite"
+)
+
+// Action is what the import does to a PediaCluster.
+type Action string
+
+const (
+	ActionCreate    Action = "Create"
+	ActionUpdate    Action = "Update"
+	ActionSkip      Action = "Skip"
+	ActionConflict  Action = "Conflict"
+	ActionUnchanged Action = "Unchanged"
+)
+
+type ImportOptions struct {
+	// Conflict is the policy of the existing PediaClusters, defaults to ConflictFail.
+	Conflict ConflictPolicy
+
+	// DryRun plans the import without writing anything.
+	DryRun bool
+
+	// Key decrypts the credentials of a bundle exported with CredentialsEncrypted.
+	Key []byte
+}
+
+// Result is the import of a PediaCluster. Diff is the change of the existing
+// PediaCluster, the credentials are compared by their digest.
+type Result struct {
+	Name    string
+	Action  Action
+	Diff    string
+	Message string
+	Err     error
+}
+
+// ConflictError is returned by ConflictFail if some PediaClusters of the bundle
+// exist with a different spec, labels or annotations.
+type ConflictError struct {
+	Clusters []string
+}
+
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("PediaClusters %s exist with different specs", strings.Join(e.Clusters, ", "))
+}
+
+// Import creates the PediaClusters of the bundle, the existing ones are handled
+// by the conflict policy. Every PediaCluster is planned before any is written,
+// so an invalid bundle or a conflict with ConflictFail writes nothing.
+// The credentials of an existing PediaCluster are kept if they are redacted in the bundle.
+func Import(ctx context.Context, clusters clusterv1alpha2client.PediaClusterInterface, bundle *clusterv1alpha2.PediaClusterList, opts ImportOptions) ([]Result, error) {
+	policy := opts.Conflict
+	if policy == "" {
+		policy = ConflictFail
+	}
+	switch policy {
+	case ConflictFail, ConflictSkip, ConflictOverwrite:
+	default:
+		return nil, fmt.Errorf("unsupported conflict policy %q", policy)
+	}
+
+	results := make([]Result, 0, len(bundle.Items))
+	desired := make([]*clusterv1alpha2.PediaCluster, 0, len(bundle.Items))
+	var conflicts []string
+	for i := range bundle.Items {
+		result, cluster, err := plan(ctx, clusters, &bundle.Items[i], policy, opts.Key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to import PediaCluster %s: %w", bundle.Items[i].Name, err)
+		}
+		if result.Action == ActionConflict {
+			conflicts = append(conflicts, result.Name)
+		}
+		results = append(results, result)
+		desired = append(desired, cluster)
+	}
+	if len(conflicts) != 0 {
+		return results, &ConflictError{Clusters: conflicts}
+	}
+	if opts.DryRun {
+		return results, nil
+	}
+
+	var errs []error
+	for i := range results {
+		result := &results[i]
+		switch result.Action {
+		case ActionCreate:
+			_, result.Err = clusters.Create(ctx, desired[i], metav1.CreateOptions{})
+		case ActionUpdate:
+			_, result.Err = clusters.Update(ctx, desired[i], metav1.UpdateOptions{})
+		}
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to %s PediaCluster %s: %w", strings.ToLower(string(result.Action)), result.Name, result.Err))
+		}
+	}
+	return results, utilerrors.NewAggregate(errs)
+}
+
+// plan returns the action of a PediaCluster of the bundle and the object it is
+// created or updated with.
+func plan(ctx context.Context, clusters clusterv1alpha2client.PediaClusterInterface, item *clusterv1alpha2.PediaCluster, policy ConflictPolicy, key []byte) (Result, *clusterv1alpha2.PediaCluster, error) {
+	cluster := sanitize(item)
+	redacted, err := openCredentials(cluster, key)
+	if err != nil {
+		return Result{}, nil, err
+	}
+	result := Result{Name: cluster.Name}
+
+	existing, err := clusters.Get(ctx, cluster.Name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		result.Action = ActionCreate
+		result.Diff = cmp.Diff(clusterView{}, viewOf(cluster))
+		if redacted {
+			result.Message = "credentials are redacted, set them before the cluster can be synchronized"
+		}
+		return result, cluster, nil
+	}
+	if err != nil {
+		return Result{}, nil, err
+	}
+
+	updated := existing.DeepCopy()
+	spec := cluster.Spec
+	if redacted {
+		// the credentials only make sense with the connection they belong to
+		if err := sameConnection(existing.Spec, spec); err != nil {
+			return Result{}, nil, fmt.Errorf("credentials are redacted, %w", err)
+		}
+		spec.Kubeconfig, spec.APIServer, spec.CAData = existing.Spec.Kubeconfig, existing.Spec.APIServer, existing.Spec.CAData
+		spec.TokenData, spec.CertData, spec.KeyData = existing.Spec.TokenData, existing.Spec.CertData, existing.Spec.KeyData
+	}
+	updated.Spec = spec
+	updated.Labels = mergeMap(updated.Labels, cluster.Labels)
+	updated.Annotations = mergeMap(updated.Annotations, cluster.Annotations)
+
+	if equality.Semantic.DeepEqual(viewOf(existing), viewOf(updated)) {
+		result.Action = ActionUnchanged
+		return result, updated, nil
+	}
+	result.Diff = cmp.Diff(viewOf(existing), viewOf(updated))
+	switch policy {
+	case ConflictSkip:
+		result.Action = ActionSkip
+	case ConflictOverwrite:
+		result.Action = ActionUpdate
+	default:
+		result.Action = ActionConflict
+	}
+	return result, updated, nil
+}
+
+// sameConnection returns an error if the bundle connects to the member cluster
+// another way than the existing PediaCluster. The kubeconfigs are compared by
+// the cluster of their current context, since the redacted one has no users.
+func sameConnection(existing, imported clusterv1alpha2.ClusterSpec) error {
+	switch existingKubeconfig, importedKubeconfig := len(existing.Kubeconfig) != 0, len(imported.Kubeconfig) != 0; {
+	case existingKubeconfig && !importedKubeconfig:
+		return errors.New("the existing PediaCluster connects with a kubeconfig, but the bundle with an apiserver")
+	case !existingKubeconfig && importedKubeconfig:
+		return errors.New("the existing PediaCluster connects with an apiserver, but the bundle with a kubeconfig")
+	case !existingKubeconfig && existing.APIServer != imported.APIServer:
+		return fmt.Errorf("the existing PediaCluster connects to %s, but the bundle to %s", existing.APIServer, imported.APIServer)
+	case !existingKubeconfig:
+		return nil
+	}
+
+	existingCluster, err := currentCluster(existing.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("the kubeconfig of the existing PediaCluster is invalid: %w", err)
+	}
+	importedCluster, err := currentCluster(imported.Kubeconfig)
+	if err != nil {
+		return fmt.Errorf("the kubeconfig of the bundle is invalid: %w", err)
+	}
+	switch {
+	case existingCluster.Server != importedCluster.Server:
+		return fmt.Errorf("the existing PediaCluster connects to %s, but the bundle to %s", existingCluster.Server, importedCluster.Server)
+	case !bytes.Equal(existingCluster.CertificateAuthorityData, importedCluster.CertificateAuthorityData) ||
+		existingCluster.CertificateAuthority != importedCluster.CertificateAuthority:
+		return errors.New("the existing PediaCluster trusts another CA than the bundle")
+	case existingCluster.InsecureSkipTLSVerify != importedCluster.InsecureSkipTLSVerify:
+		return fmt.Errorf("the existing PediaCluster sets insecure-skip-tls-verify to %t, but the bundle to %t",
+			existingCluster.InsecureSkipTLSVerify, importedCluster.InsecureSkipTLSVerify)
+	}
+	return nil
+}
+
+// currentCluster returns the cluster of the current context of the kubeconfig.
+func currentCluster(kubeconfig []byte) (*clientcmdapi.Cluster, error) {
+	config, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("context %q is not found in kubeconfig", config.CurrentContext)
+	}
+	cluster, ok := config.Clusters[kubeContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q of context %q is not found in kubeconfig", kubeContext.Cluster, config.CurrentContext)
+	}
+	return cluster, nil
+}
+
+func mergeMap(existing, imported map[string]string) map[string]string {
+	if len(imported) == 0 {
+		return existing
+	}
+	merged := make(map[string]string, len(existing)+len(imported))
+	for k, v := range existing {
+		merged[k] = v
+	}
+	for k, v := range imported {
+		merged[k] = v
+	}
+	return merged
+}
+
+// clusterView is the imported fields of a PediaCluster, the credentials are
+// replaced by their digest so that a diff does not disclose them.
+type clusterView struct {
+	Labels      map[string]string
+	Annotations map[string]string
+
+	Kubeconfig string
+	APIServer  string
+	TokenData  string
+	CAData     string
+	CertData   string
+	KeyData    string
+
+	SyncResources          []clusterv1alpha2.ClusterGroupResources
+	SyncAllCustomResources bool
+	SyncResourcesRefName   string
+}
+
+func viewOf(cluster *clusterv1alpha2.PediaCluster) clusterView {
+	return clusterView{
+		Labels:                 cluster.Labels,
+		Annotations:            cluster.Annotations,
+		Kubeconfig:             digest(cluster.Spec.Kubeconfig),
+		APIServer:              cluster.Spec.APIServer,
+		TokenData:              digest(cluster.Spec.TokenData),
+		CAData:                 digest(cluster.Spec.CAData),
+		CertData:               digest(cluster.Spec.CertData),
+		KeyData:                digest(cluster.Spec.KeyData),
+		SyncResources:          cluster.Spec.SyncResources,
+		SyncAllCustomResources: cluster.Spec.SyncAllCustomResources,
+		SyncResourcesRefName:   cluster.Spec.SyncResourcesRefName,
+	}
+}
+
+func digest(data []byte) string {
+	if len(data) == 0 {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return "sha256:" + hex.EncodeToString(sum[:6])
+}
@@ -0,0 +1,170 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+
+	clusterv1alpha2client "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/typed/cluster/v1alpha2"
+)
+
+const DefaultPageSize int64 = 100
+
+// Format is the encoding of a bundle.
+type Format string
+
+const (
+	FormatYAML Format = "yaml"
+	FormatJSON Format = "json"
+)
+
+type ExportOptions struct {
+	// ListOptions select the exported PediaClusters, such as by a label selector.
+	ListOptions metav1.ListOptions
+
+	// Credentials is how the credentials are exported, defaults to CredentialsRedacted.
+	Credentials CredentialMode
+
+	// Key is the AES key of 16, 24 or 32 bytes encrypting the credentials
+	// with CredentialsEncrypted.
+	Key []byte
+}
+
+// Export returns the bundle of the PediaClusters sorted by name. A bundle is a
+// PediaClusterList without the status and the server-populated metadata, so it
+// can also be applied by kubectl when the credentials are not encrypted.
+func Export(ctx context.Context, clusters clusterv1alpha2client.PediaClusterInterface, opts ExportOptions) (*clusterv1alpha2.PediaClusterList, error) {
+	mode := opts.Credentials
+	if mode == "" {
+		mode = CredentialsRedacted
+	}
+	seal, err := credentialSealer(mode, opts.Key)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &clusterv1alpha2.PediaClusterList{
+		TypeMeta: metav1.TypeMeta{APIVersion: clusterv1alpha2.SchemeGroupVersion.String(), Kind: "PediaClusterList"},
+	}
+	listOptions := opts.ListOptions
+	if listOptions.Limit == 0 {
+		listOptions.Limit = DefaultPageSize
+	}
+	for {
+		list, err := clusters.List(ctx, listOptions)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			cluster := sanitize(&list.Items[i])
+			if err := seal(cluster); err != nil {
+				return nil, fmt.Errorf("failed to export the credentials of PediaCluster %s: %w", cluster.Name, err)
+			}
+			bundle.Items = append(bundle.Items, *cluster)
+		}
+
+		listOptions.Continue = list.Continue
+		if listOptions.Continue == "" {
+			break
+		}
+	}
+
+	sort.Slice(bundle.Items, func(i, j int) bool { return bundle.Items[i].Name < bundle.Items[j].Name })
+	return bundle, nil
+}
+
+// sanitize returns the portable fields of the cluster, the last applied
+// configuration is dropped since it may hold the credentials.
+func sanitize(cluster *clusterv1alpha2.PediaCluster) *clusterv1alpha2.PediaCluster {
+	out := &clusterv1alpha2.PediaCluster{
+		TypeMeta: metav1.TypeMeta{APIVersion: clusterv1alpha2.SchemeGroupVersion.String(), Kind: "PediaCluster"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        cluster.Name,
+			Labels:      cluster.Labels,
+			Annotations: cluster.Annotations,
+		},
+		Spec: cluster.Spec,
+	}
+	out = out.DeepCopy()
+	delete(out.Annotations, corev1.LastAppliedConfigAnnotation)
+	if len(out.Annotations) == 0 {
+		out.Annotations = nil
+	}
+	return out
+}
+
+// Write encodes the bundle in format.
+func Write(w io.Writer, bundle *clusterv1alpha2.PediaClusterList, format Format) error {
+	var data []byte
+	var err error
+	switch format {
+	case FormatYAML, "":
+		data, err = yaml.Marshal(bundle)
+	case FormatJSON:
+		data, err = json.MarshalIndent(bundle, "", "  ")
+		data = append(data, '\n')
+	default:
+		return fmt.Errorf("unsupported format %q", format)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+// Read decodes a bundle in YAML or JSON, the List of PediaClusters printed by
+// kubectl get is accepted as well.
+func Read(r io.Reader) (*clusterv1alpha2.PediaClusterList, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	bundle := &clusterv1alpha2.PediaClusterList{}
+	if err := yaml.Unmarshal(data, bundle); err != nil {
+		return nil, fmt.Errorf("failed to decode the bundle: %w", err)
+	}
+	switch {
+	case bundle.APIVersion == clusterv1alpha2.SchemeGroupVersion.String() && bundle.Kind == "PediaClusterList":
+	case bundle.APIVersion == "v1" && bundle.Kind == "List":
+		// the output of kubectl get, every item carries its own type
+		for _, cluster := range bundle.Items {
+			if cluster.APIVersion != clusterv1alpha2.SchemeGroupVersion.String() || cluster.Kind != "PediaCluster" {
+				return nil, fmt.Errorf("bundle has a %s %s, not a %s PediaCluster", cluster.APIVersion, cluster.Kind, clusterv1alpha2.SchemeGroupVersion)
+			}
+		}
+		bundle.TypeMeta = metav1.TypeMeta{APIVersion: clusterv1alpha2.SchemeGroupVersion.String(), Kind: "PediaClusterList"}
+	default:
+		return nil, fmt.Errorf("bundle is %s %s, not a %s PediaClusterList", bundle.APIVersion, bundle.Kind, clusterv1alpha2.SchemeGroupVersion)
+	}
+	for _, cluster := range bundle.Items {
+		if cluster.Name == "" {
+			return nil, fmt.Errorf("bundle has a PediaCluster without name")
+		}
+	}
+	return bundle, nil
+}
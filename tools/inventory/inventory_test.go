@@ -0,0 +1,386 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package inventory
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: member
+  cluster:
+    server: https://kubeconfig:6443
+contexts:
+- name: member
+  context:
+    cluster: member
+    user: admin
+current-context: member
+users:
+- name: admin
+  user:
+    token: kubeconfig-token
+`
+
+func newClusters() []*clusterv1alpha2.PediaCluster {
+	return []*clusterv1alpha2.PediaCluster{
+		{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            "token",
+				ResourceVersion: "10",
+				Labels:          map[string]string{"env": "prod"},
+				Annotations:     map[string]string{corev1.LastAppliedConfigAnnotation: `{"spec":{"tokenData":"c2VjcmV0LXRva2Vu"}}`},
+			},
+			Spec: clusterv1alpha2.ClusterSpec{
+				APIServer:     "https://token:6443",
+				TokenData:     []byte("secret-token"),
+				CAData:        []byte("ca"),
+				SyncResources: []clusterv1alpha2.ClusterGroupResources{{Group: "apps", Resources: []string{"deployments"}}},
+			},
+			Status: clusterv1alpha2.ClusterStatus{Version: "v1.28.0"},
+		},
+		{
+			ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig"},
+			Spec:       clusterv1alpha2.ClusterSpec{Kubeconfig: []byte(kubeconfig)},
+		},
+	}
+}
+
+func export(t *testing.T, opts ExportOptions, format Format) *clusterv1alpha2.PediaClusterList {
+	clusters := newClusters()
+	clientset := fake.NewSimpleClientset(clusters[0], clusters[1])
+	bundle, err := Export(context.Background(), clientset.ClusterV1alpha2().PediaClusters(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := Write(&buf, bundle, format); err != nil {
+		t.Fatal(err)
+	}
+	// the base64 of the token
+	if strings.Contains(buf.String(), "c2VjcmV0LXRva2Vu") != (opts.Credentials == CredentialsPlain) {
+		t.Errorf("unexpected credentials in %s bundle:\n%s", opts.Credentials, buf.String())
+	}
+
+	bundle, err = Read(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return bundle
+}
+
+func TestExport(t *testing.T) {
+	for _, format := range []Format{FormatYAML, FormatJSON} {
+		bundle := export(t, ExportOptions{}, format)
+		if len(bundle.Items) != 2 || bundle.Items[0].Name != "kubeconfig" || bundle.Items[1].Name != "token" {
+			t.Fatalf("expected the clusters sorted by name, got %+v", bundle.Items)
+		}
+
+		cluster := bundle.Items[1]
+		if cluster.ResourceVersion != "" || cluster.Status.Version != "" || cluster.Labels["env"] != "prod" {
+			t.Errorf("expected the portable fields of the cluster, got %+v", cluster)
+		}
+		if cluster.Annotations[AnnotationCredentials] != string(CredentialsRedacted) || len(cluster.Annotations) != 1 {
+			t.Errorf("expected only the redacted annotation, got %v", cluster.Annotations)
+		}
+		if len(cluster.Spec.TokenData) != 0 || string(cluster.Spec.CAData) != "ca" || cluster.Spec.APIServer != "https://token:6443" {
+			t.Errorf("expected the token is redacted, got %+v", cluster.Spec)
+		}
+
+		config, err := clientcmd.Load(bundle.Items[0].Spec.Kubeconfig)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if config.AuthInfos["admin"].Token != "" || config.Clusters["member"].Server != "https://kubeconfig:6443" {
+			t.Errorf("expected the token of the kubeconfig is redacted, got %+v", config)
+		}
+	}
+
+	export(t, ExportOptions{Credentials: CredentialsPlain}, FormatYAML)
+
+	if _, err := Read(strings.NewReader("apiVersion: v1\nkind: ConfigMapList\n")); err == nil {
+		t.Error("expected error of a bundle that is not a PediaClusterList")
+	}
+}
+
+func TestReadList(t *testing.T) {
+	const list = `apiVersion: v1
+kind: List
+items:
+- apiVersion: cluster.clusterpedia.io/v1alpha2
+  kind: PediaCluster
+  metadata:
+    name: member
+    resourceVersion: "10"
+  spec:
+    apiserver: https://member:6443
+    syncResources: []
+  status:
+    version: v1.28.0
+`
+	bundle, err := Read(strings.NewReader(list))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bundle.Kind != "PediaClusterList" || len(bundle.Items) != 1 || bundle.Items[0].Spec.APIServer != "https://member:6443" {
+		t.Errorf("unexpected bundle: %+v", bundle)
+	}
+
+	mixed := strings.Replace(list, "kind: PediaCluster", "kind: ConfigMap", 1)
+	if _, err := Read(strings.NewReader(mixed)); err == nil || !strings.Contains(err.Error(), "ConfigMap") {
+		t.Errorf("expected error of a List with another kind, got %v", err)
+	}
+}
+
+func TestImportEncrypted(t *testing.T) {
+	key := bytes.Repeat([]byte("k"), 32)
+	bundle := export(t, ExportOptions{Credentials: CredentialsEncrypted, Key: key}, FormatYAML)
+
+	clientset := fake.NewSimpleClientset()
+	clusters := clientset.ClusterV1alpha2().PediaClusters()
+	if _, err := Import(context.Background(), clusters, bundle, ImportOptions{}); err == nil || !strings.Contains(err.Error(), "no key") {
+		t.Errorf("expected error of the missing key, got %v", err)
+	}
+	if _, err := Import(context.Background(), clusters, bundle, ImportOptions{Key: bytes.Repeat([]byte("x"), 32)}); err == nil {
+		t.Error("expected error of the wrong key")
+	}
+
+	results, err := Import(context.Background(), clusters, bundle, ImportOptions{Key: key})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, result := range results {
+		if result.Action != ActionCreate {
+			t.Errorf("expected %s is created, got %s", result.Name, result.Action)
+		}
+	}
+
+	cluster, err := clusters.Get(context.Background(), "token", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cluster.Spec.TokenData) != "secret-token" || cluster.Annotations[AnnotationCredentials] != "" {
+		t.Errorf("expected the decrypted token without annotation, got %+v", cluster)
+	}
+	cluster, err = clusters.Get(context.Background(), "kubeconfig", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cluster.Spec.Kubeconfig) != kubeconfig {
+		t.Errorf("expected the decrypted kubeconfig, got %q", cluster.Spec.Kubeconfig)
+	}
+}
+
+func TestImportConflicts(t *testing.T) {
+	bundle := export(t, ExportOptions{Credentials: CredentialsRedacted}, FormatYAML)
+
+	newTarget := func() *fake.Clientset {
+		existing := newClusters()[0]
+		existing.Spec.SyncResources = nil
+		return fake.NewSimpleClientset(existing)
+	}
+
+	tests := []struct {
+		name       string
+		opts       ImportOptions
+		wantAction Action
+		wantErr    bool
+		wantSynced bool
+	}{
+		{name: "fail", opts: ImportOptions{}, wantAction: ActionConflict, wantErr: true},
+		{name: "skip", opts: ImportOptions{Conflict: ConflictSkip}, wantAction: ActionSkip},
+		{name: "dry run", opts: ImportOptions{Conflict: ConflictOverwrite, DryRun: true}, wantAction: ActionUpdate},
+		{name: "overwrite", opts: ImportOptions{Conflict: ConflictOverwrite}, wantAction: ActionUpdate, wantSynced: true},
+	}
+	for _, tt := range tests {
+		clientset := newTarget()
+		clusters := clientset.ClusterV1alpha2().PediaClusters()
+
+		results, err := Import(context.Background(), clusters, bundle, tt.opts)
+		var conflict *ConflictError
+		if tt.wantErr != errors.As(err, &conflict) || !tt.wantErr && err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+		}
+		if len(results) != 2 || results[0].Action != ActionCreate || results[1].Action != tt.wantAction {
+			t.Fatalf("%s: unexpected results %+v", tt.name, results)
+		}
+		if !strings.Contains(results[1].Diff, "deployments") || strings.Contains(results[1].Diff, "secret-token") {
+			t.Errorf("%s: unexpected diff:\n%s", tt.name, results[1].Diff)
+		}
+		if results[0].Message == "" {
+			t.Errorf("%s: expected a message of the redacted credentials", tt.name)
+		}
+
+		_, err = clusters.Get(context.Background(), "kubeconfig", metav1.GetOptions{})
+		if created := err == nil; created != (tt.wantAction != ActionConflict && !tt.opts.DryRun) {
+			t.Errorf("%s: unexpected creation of kubeconfig cluster: %v", tt.name, created)
+		}
+
+		cluster, err := clusters.Get(context.Background(), "token", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if synced := len(cluster.Spec.SyncResources) != 0; synced != tt.wantSynced {
+			t.Errorf("%s: expected the spec is updated %v, got %+v", tt.name, tt.wantSynced, cluster.Spec)
+		}
+		if string(cluster.Spec.TokenData) != "secret-token" {
+			t.Errorf("%s: expected the existing token is kept, got %q", tt.name, cluster.Spec.TokenData)
+		}
+	}
+
+	clientset := fake.NewSimpleClientset(newClusters()[0])
+	results, err := Import(context.Background(), clientset.ClusterV1alpha2().PediaClusters(), bundle, ImportOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if results[1].Action != ActionUnchanged {
+		t.Errorf("expected the existing cluster is unchanged, got %+v", results[1])
+	}
+}
+
+func TestImportRedactedConnection(t *testing.T) {
+	bundle := export(t, ExportOptions{Credentials: CredentialsRedacted}, FormatYAML)
+
+	tests := []struct {
+		name     string
+		existing func(cluster *clusterv1alpha2.PediaCluster)
+		wantErr  string
+	}{
+		{
+			name: "kubeconfig replaced by apiserver",
+			existing: func(cluster *clusterv1alpha2.PediaCluster) {
+				cluster.Spec = clusterv1alpha2.ClusterSpec{Kubeconfig: []byte(kubeconfig)}
+			},
+			wantErr: "connects with a kubeconfig, but the bundle with an apiserver",
+		},
+		{
+			name: "apiserver moved",
+			existing: func(cluster *clusterv1alpha2.PediaCluster) {
+				cluster.Spec.APIServer = "https://old:6443"
+			},
+			wantErr: "connects to https://old:6443, but the bundle to https://token:6443",
+		},
+		{
+			name: "same connection",
+			existing: func(cluster *clusterv1alpha2.PediaCluster) {
+				cluster.Spec.CertData, cluster.Spec.KeyData = []byte("cert"), []byte("key")
+			},
+		},
+	}
+	for _, tt := range tests {
+		existing := newClusters()[0]
+		tt.existing(existing)
+		clusters := fake.NewSimpleClientset(existing).ClusterV1alpha2().PediaClusters()
+
+		_, err := Import(context.Background(), clusters, bundle, ImportOptions{Conflict: ConflictOverwrite})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		cluster, err := clusters.Get(context.Background(), "token", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if string(cluster.Spec.TokenData) != "secret-token" || string(cluster.Spec.CertData) != "cert" || string(cluster.Spec.KeyData) != "key" || len(cluster.Spec.Kubeconfig) != 0 {
+			t.Errorf("%s: expected the existing credentials are kept as a whole, got %+v", tt.name, cluster.Spec)
+		}
+	}
+}
+
+func TestImportRedactedKubeconfig(t *testing.T) {
+	bundle := export(t, ExportOptions{Credentials: CredentialsRedacted}, FormatYAML)
+
+	tests := []struct {
+		name    string
+		cluster func(cluster *clientcmdapi.Cluster)
+		wantErr string
+	}{
+		{
+			name:    "server moved",
+			cluster: func(cluster *clientcmdapi.Cluster) { cluster.Server = "https://old:6443" },
+			wantErr: "connects to https://old:6443, but the bundle to https://kubeconfig:6443",
+		},
+		{
+			name:    "another CA",
+			cluster: func(cluster *clientcmdapi.Cluster) { cluster.CertificateAuthorityData = []byte("ca") },
+			wantErr: "trusts another CA than the bundle",
+		},
+		{
+			name:    "insecure",
+			cluster: func(cluster *clientcmdapi.Cluster) { cluster.InsecureSkipTLSVerify = true },
+			wantErr: "sets insecure-skip-tls-verify to true, but the bundle to false",
+		},
+		{
+			name:    "same connection",
+			cluster: func(cluster *clientcmdapi.Cluster) {},
+		},
+	}
+	for _, tt := range tests {
+		config, err := clientcmd.Load([]byte(kubeconfig))
+		if err != nil {
+			t.Fatal(err)
+		}
+		tt.cluster(config.Clusters["member"])
+		existingKubeconfig, err := clientcmd.Write(*config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		existing := newClusters()[1]
+		existing.Spec.Kubeconfig = existingKubeconfig
+		clusters := fake.NewSimpleClientset(existing).ClusterV1alpha2().PediaClusters()
+
+		_, err = Import(context.Background(), clusters, bundle, ImportOptions{Conflict: ConflictOverwrite})
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%s: expected error %q, got %v", tt.name, tt.wantErr, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tt.name, err)
+		}
+
+		cluster, err := clusters.Get(context.Background(), "kubeconfig", metav1.GetOptions{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(cluster.Spec.Kubeconfig, existingKubeconfig) {
+			t.Errorf("%s: expected the existing kubeconfig is kept, got %q", tt.name, cluster.Spec.Kubeconfig)
+		}
+	}
+}
@@ -0,0 +1,182 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"context"
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+
+	"github.com/clusterpedia-io/client-go/client"
+	"github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned"
+)
+
+const (
+	DefaultContextPrefix      = "pedia-"
+	DefaultAllClustersContext = "pedia"
+	DefaultProxyContextSuffix = "-proxy"
+)
+
+type Options struct {
+	// Context is the context of the base kubeconfig pointing at the host
+	// cluster of clusterpedia, defaults to the current context.
+	Context string
+
+	// ContextPrefix is prepended to the cluster name to name the context
+	// of a cluster, defaults to DefaultContextPrefix.
+	ContextPrefix string
+
+	// AllClustersContext names the context searching all clusters,
+	// defaults to DefaultAllClustersContext.
+	AllClustersContext string
+
+	// Proxy also generates a context per cluster that reaches the member
+	// cluster through the proxy path, named with DefaultProxyContextSuffix.
+	Proxy bool
+
+	// Overwrite replaces the clusters and contexts of the base kubeconfig
+	// named as the generated ones, such as the contexts generated before.
+	// Generate fails on such a collision if it is false. The generated
+	// contexts colliding with each other always fail.
+	Overwrite bool
+}
+
+func (opts *Options) complete(base *clientcmdapi.Config) error {
+	if opts.Context == "" {
+		opts.Context = base.CurrentContext
+	}
+	if _, ok := base.Contexts[opts.Context]; !ok {
+		return fmt.Errorf("context %q is not found in kubeconfig", opts.Context)
+	}
+	if opts.ContextPrefix == "" {
+		opts.ContextPrefix = DefaultContextPrefix
+	}
+	if opts.AllClustersContext == "" {
+		opts.AllClustersContext = DefaultAllClustersContext
+	}
+	return nil
+}
+
+// Generate returns a copy of base with a context per cluster pointing at the
+// cluster in clusterpedia, and a context searching all clusters. The generated
+// contexts reuse the user and the namespace of the base context, and are named
+// after their cluster in the kubeconfig.
+func Generate(base *clientcmdapi.Config, clusters []string, opts Options) (*clientcmdapi.Config, error) {
+	if err := opts.complete(base); err != nil {
+		return nil, err
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*base, opts.Context, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+
+	config := base.DeepCopy()
+	baseContext := base.Contexts[opts.Context]
+	baseCluster, ok := base.Clusters[baseContext.Cluster]
+	if !ok {
+		return nil, fmt.Errorf("cluster %q of context %q is not found in kubeconfig", baseContext.Cluster, opts.Context)
+	}
+
+	generated := make(map[string]struct{})
+	addContext := func(name string, cfg *rest.Config) error {
+		// the names generated in this run never overwrite each other, such as
+		// the proxy context of cluster "a" and the context of cluster "a-proxy"
+		if _, ok := generated[name]; ok {
+			return fmt.Errorf("context %q is generated more than once", name)
+		}
+		generated[name] = struct{}{}
+
+		if !opts.Overwrite {
+			if _, ok := base.Clusters[name]; ok {
+				return fmt.Errorf("cluster %q already exists in kubeconfig", name)
+			}
+			if _, ok := base.Contexts[name]; ok {
+				return fmt.Errorf("context %q already exists in kubeconfig", name)
+			}
+		}
+
+		cluster := baseCluster.DeepCopy()
+		cluster.Server = cfg.Host
+		config.Clusters[name] = cluster
+
+		kubeContext := baseContext.DeepCopy()
+		kubeContext.Cluster = name
+		config.Contexts[name] = kubeContext
+		return nil
+	}
+
+	pediaConfig, err := client.ConfigFor(restConfig)
+	if err != nil {
+		return nil, err
+	}
+	if err := addContext(opts.AllClustersContext, pediaConfig); err != nil {
+		return nil, err
+	}
+
+	for _, cluster := range clusters {
+		clusterConfig, err := client.ClusterConfigFor(restConfig, cluster)
+		if err != nil {
+			return nil, err
+		}
+		if err := addContext(opts.ContextPrefix+cluster, clusterConfig); err != nil {
+			return nil, err
+		}
+
+		if opts.Proxy {
+			proxyConfig, err := client.ProxyClusterConfigFor(restConfig, cluster)
+			if err != nil {
+				return nil, err
+			}
+			if err := addContext(opts.ContextPrefix+cluster+DefaultProxyContextSuffix, proxyConfig); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return config, nil
+}
+
+// GenerateForPediaClusters is like Generate for all the PediaClusters listed
+// with the base context.
+func GenerateForPediaClusters(ctx context.Context, base *clientcmdapi.Config, opts Options) (*clientcmdapi.Config, error) {
+	if err := opts.complete(base); err != nil {
+		return nil, err
+	}
+
+	restConfig, err := clientcmd.NewNonInteractiveClientConfig(*base, opts.Context, &clientcmd.ConfigOverrides{}, nil).ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	pediaClient, err := versioned.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	pediaClusters, err := pediaClient.ClusterV1alpha2().PediaClusters().List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	clusters := make([]string, 0, len(pediaClusters.Items))
+	for _, cluster := range pediaClusters.Items {
+		clusters = append(clusters, cluster.Name)
+	}
+	return Generate(base, clusters, opts)
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package kubeconfig
+
+import (
+	"strings"
+	"testing"
+
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+const host = "https://10.6.0.1:6443"
+
+func newBase() *clientcmdapi.Config {
+	config := clientcmdapi.NewConfig()
+	config.Clusters["host"] = &clientcmdapi.Cluster{Server: host, CertificateAuthorityData: []byte("ca")}
+	config.Clusters["other"] = &clientcmdapi.Cluster{Server: "https://10.6.0.2:6443"}
+	config.AuthInfos["admin"] = &clientcmdapi.AuthInfo{Token: "token"}
+	config.Contexts["host"] = &clientcmdapi.Context{Cluster: "host", AuthInfo: "admin", Namespace: "kube-system"}
+	config.Contexts["other"] = &clientcmdapi.Context{Cluster: "other", AuthInfo: "admin"}
+	config.CurrentContext = "host"
+	return config
+}
+
+func TestGenerate(t *testing.T) {
+	const pedia = host + "/apis/clusterpedia.io/v1beta1/resources"
+
+	testCase := []struct {
+		name     string
+		base     func() *clientcmdapi.Config
+		clusters []string
+		opts     Options
+		expect   map[string]string
+		err      string
+	}{
+		{
+			name:     "contexts of clusters",
+			base:     newBase,
+			clusters: []string{"cluster-01", "cluster-02"},
+			expect: map[string]string{
+				"pedia":            pedia,
+				"pedia-cluster-01": pedia + "/clusters/cluster-01",
+				"pedia-cluster-02": pedia + "/clusters/cluster-02",
+			},
+		},
+		{
+			name:     "proxy contexts with prefix",
+			base:     newBase,
+			clusters: []string{"cluster-01"},
+			opts:     Options{ContextPrefix: "cp-", AllClustersContext: "all", Proxy: true},
+			expect: map[string]string{
+				"all":                 pedia,
+				"cp-cluster-01":       pedia + "/clusters/cluster-01",
+				"cp-cluster-01-proxy": pedia + "/clusters/cluster-01/proxy",
+			},
+		},
+		{
+			name: "context of clusterpedia path",
+			base: func() *clientcmdapi.Config {
+				base := newBase()
+				base.Clusters["host"].Server = pedia + "/clusters/cluster-01/proxy"
+				return base
+			},
+			clusters: []string{"cluster-01"},
+			expect: map[string]string{
+				"pedia":            pedia,
+				"pedia-cluster-01": pedia + "/clusters/cluster-01",
+			},
+		},
+		{
+			name: "merge with generated contexts",
+			base: func() *clientcmdapi.Config {
+				base, err := Generate(newBase(), []string{"cluster-01"}, Options{})
+				if err != nil {
+					t.Fatalf("Unexpect error: %v", err)
+				}
+				base.Clusters["pedia-cluster-01"].Server = "https://stale"
+				return base
+			},
+			clusters: []string{"cluster-01", "cluster-02"},
+			opts:     Options{Overwrite: true},
+			expect: map[string]string{
+				"pedia":            pedia,
+				"pedia-cluster-01": pedia + "/clusters/cluster-01",
+				"pedia-cluster-02": pedia + "/clusters/cluster-02",
+			},
+		},
+		{
+			name: "collision with existing context",
+			base: func() *clientcmdapi.Config {
+				base := newBase()
+				base.Contexts["pedia-cluster-01"] = &clientcmdapi.Context{Cluster: "other", AuthInfo: "admin"}
+				return base
+			},
+			clusters: []string{"cluster-01"},
+			err:      `context "pedia-cluster-01" already exists`,
+		},
+		{
+			name: "collision with existing cluster",
+			base: func() *clientcmdapi.Config {
+				base := newBase()
+				base.Clusters["pedia"] = &clientcmdapi.Cluster{Server: "https://10.6.0.3:6443"}
+				return base
+			},
+			err: `cluster "pedia" already exists`,
+		},
+		{
+			name:     "collision between generated contexts",
+			base:     newBase,
+			clusters: []string{"a", "a-proxy"},
+			opts:     Options{Proxy: true, Overwrite: true},
+			err:      `context "pedia-a-proxy" is generated more than once`,
+		},
+		{
+			name: "context not found",
+			base: newBase,
+			opts: Options{Context: "missing"},
+			err:  `context "missing" is not found`,
+		},
+	}
+
+	for _, tc := range testCase {
+		t.Run(tc.name, func(t *testing.T) {
+			base := tc.base()
+			original := base.DeepCopy()
+
+			config, err := Generate(base, tc.clusters, tc.opts)
+			if tc.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tc.err) {
+					t.Fatalf("Unexpect error: %v, expect: %s", err, tc.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+
+			for name, server := range tc.expect {
+				context, cluster := config.Contexts[name], config.Clusters[name]
+				if context == nil || cluster == nil {
+					t.Errorf("Unexpect missing context or cluster %s", name)
+					continue
+				}
+				if cluster.Server != server || string(cluster.CertificateAuthorityData) != "ca" {
+					t.Errorf("Unexpect cluster %s: %+v, expect server: %s", name, cluster, server)
+				}
+				if context.Cluster != name || context.AuthInfo != "admin" || context.Namespace != "kube-system" {
+					t.Errorf("Unexpect context %s: %+v", name, context)
+				}
+			}
+
+			// the contexts of base are kept and no user is added
+			for name, context := range original.Contexts {
+				if _, generated := tc.expect[name]; !generated && config.Contexts[name].Cluster != context.Cluster {
+					t.Errorf("Unexpect change of context %s: %+v", name, config.Contexts[name])
+				}
+			}
+			if len(config.AuthInfos) != 1 || config.AuthInfos["admin"].Token != "token" {
+				t.Errorf("Unexpect users: %v", config.AuthInfos)
+			}
+			if len(config.Contexts) != len(original.Contexts)+len(tc.expect)-countExisting(original, tc.expect) {
+				t.Errorf("Unexpect contexts: %d", len(config.Contexts))
+			}
+			if config.CurrentContext != original.CurrentContext {
+				t.Errorf("Unexpect current context: %s", config.CurrentContext)
+			}
+		})
+	}
+}
+
+func countExisting(config *clientcmdapi.Config, contexts map[string]string) int {
+	var count int
+	for name := range contexts {
+		if _, ok := config.Contexts[name]; ok {
+			count++
+		}
+	}
+	return count
+}
+
+func TestGenerateKeepsBase(t *testing.T) {
+	base := newBase()
+	if _, err := Generate(base, []string{"cluster-01"}, Options{}); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if len(base.Contexts) != 2 || len(base.Clusters) != 2 {
+		t.Errorf("Unexpect change of the base kubeconfig: %v", base.Contexts)
+	}
+}
@@ -0,0 +1,130 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package listwatch
+
+import (
+	"errors"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+// ClusterIndex indexes the objects by their cluster.
+const ClusterIndex = "cluster"
+
+// ClusterIndexFunc is the cache.IndexFunc of ClusterIndex.
+func ClusterIndexFunc(obj interface{}) ([]string, error) {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return nil, err
+	}
+	return []string{shadow.ClusterOf(accessor)}, nil
+}
+
+// Indexers returns indexers with ClusterIndex added.
+func Indexers(indexers cache.Indexers) cache.Indexers {
+	out := make(cache.Indexers, len(indexers)+1)
+	for name, indexFunc := range indexers {
+		out[name] = indexFunc
+	}
+	if _, ok := out[ClusterIndex]; !ok {
+		out[ClusterIndex] = ClusterIndexFunc
+	}
+	return out
+}
+
+// KeyFunc returns <cluster>/<namespace>/<name> as the key of the object,
+// or <cluster>/<name> if the object is cluster scoped.
+func KeyFunc(obj interface{}) (string, error) {
+	if d, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		return d.Key, nil
+	}
+
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return "", err
+	}
+	return shadow.KeyOf(accessor).String(), nil
+}
+
+// Get returns the object of the cluster from an indexer of NewIndexerInformer.
+// The listers of client-go look the objects up by <namespace>/<name>, so they
+// do not work on the indexer, the objects of a cluster are listed with
+// ByIndex(ClusterIndex, cluster).
+func Get(indexer cache.Indexer, key shadow.ClusterObjectKey) (interface{}, bool, error) {
+	return indexer.GetByKey(key.String())
+}
+
+// NewIndexerInformer is like cache.NewIndexerInformer with the objects keyed by
+// KeyFunc and ClusterIndex added to the indexers, so that the objects with the
+// same namespace and name in different clusters are kept apart. The informers
+// of client-go key the objects by namespace and name only, a delete in one
+// cluster would remove the object of another.
+func NewIndexerInformer(lw cache.ListerWatcher, objType runtime.Object, resyncPeriod time.Duration, h cache.ResourceEventHandler, indexers cache.Indexers) (cache.Indexer, cache.Controller) {
+	clientState := cache.NewIndexer(KeyFunc, Indexers(indexers))
+
+	fifo := cache.NewDeltaFIFOWithOptions(cache.DeltaFIFOOptions{
+		KeyFunction:           KeyFunc,
+		KnownObjects:          clientState,
+		EmitDeltaTypeReplaced: true,
+	})
+	return clientState, cache.New(&cache.Config{
+		Queue:            fifo,
+		ListerWatcher:    lw,
+		ObjectType:       objType,
+		FullResyncPeriod: resyncPeriod,
+		Process: func(obj interface{}, isInInitialList bool) error {
+			deltas, ok := obj.(cache.Deltas)
+			if !ok {
+				return errors.New("object given as Process argument is not Deltas")
+			}
+			return processDeltas(h, clientState, deltas, isInInitialList)
+		},
+	})
+}
+
+func processDeltas(h cache.ResourceEventHandler, clientState cache.Store, deltas cache.Deltas, isInInitialList bool) error {
+	// from oldest to newest
+	for _, d := range deltas {
+		obj := d.Object
+
+		switch d.Type {
+		case cache.Sync, cache.Replaced, cache.Added, cache.Updated:
+			if old, exists, err := clientState.Get(obj); err == nil && exists {
+				if err := clientState.Update(obj); err != nil {
+					return err
+				}
+				h.OnUpdate(old, obj)
+			} else {
+				if err := clientState.Add(obj); err != nil {
+					return err
+				}
+				h.OnAdd(obj, isInInitialList)
+			}
+		case cache.Deleted:
+			if err := clientState.Delete(obj); err != nil {
+				return err
+			}
+			h.OnDelete(obj)
+		}
+	}
+	return nil
+}
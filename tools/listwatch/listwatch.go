@@ -0,0 +1,278 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package listwatch
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/clusterpedia-io/client-go/tools/builder"
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+const (
+	DefaultInterval       = 30 * time.Second
+	DefaultPageSize int64 = 500
+)
+
+type Options struct {
+	// Interval is the period of re-listing the search, defaults to DefaultInterval.
+	Interval time.Duration
+
+	// PageSize is the limit of every page of the search, defaults to DefaultPageSize.
+	PageSize int64
+}
+
+var _ cache.ListerWatcher = &ListWatch{}
+
+// ListWatch is a cache.ListerWatcher over a clusterpedia search for storage
+// layers not supporting watch. The watch re-lists the search periodically and
+// synthesizes the Added, Modified and Deleted events by comparing the objects
+// by their cluster, UID and resourceVersion.
+type ListWatch struct {
+	ctx      context.Context
+	client   client.Client
+	list     client.ObjectList
+	options  metav1.ListOptions
+	interval time.Duration
+	pageSize int64
+
+	lock     sync.Mutex
+	revision uint64
+	objects  map[objectKey]runtime.Object
+}
+
+type objectKey struct {
+	cluster string
+	uid     types.UID
+}
+
+// New returns a ListWatch for the search built by query, list is the type of
+// list the search returns, such as &appsv1.DeploymentList{}.
+func New(c client.Client, list client.ObjectList, query builder.ListOptionsInterface, opts Options) *ListWatch {
+	lw := &ListWatch{
+		ctx:      context.Background(),
+		client:   c,
+		list:     list,
+		options:  query.Options(),
+		interval: opts.Interval,
+		pageSize: opts.PageSize,
+		objects:  make(map[objectKey]runtime.Object),
+	}
+	if lw.interval <= 0 {
+		lw.interval = DefaultInterval
+	}
+	if lw.pageSize <= 0 {
+		lw.pageSize = DefaultPageSize
+	}
+	return lw
+}
+
+// WithContext sets the context of the lists and watches, they are canceled
+// with ctx. It must be called before the ListWatch is used.
+func (lw *ListWatch) WithContext(ctx context.Context) *ListWatch {
+	lw.ctx = ctx
+	return lw
+}
+
+// List lists all pages of the search, the options are ignored.
+func (lw *ListWatch) List(options metav1.ListOptions) (runtime.Object, error) {
+	objs, err := lw.listAll(lw.ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lw.lock.Lock()
+	lw.objects = make(map[objectKey]runtime.Object, len(objs))
+	for _, obj := range objs {
+		lw.objects[keyOf(obj)] = obj
+	}
+	lw.revision++
+	revision := lw.revision
+	lw.lock.Unlock()
+
+	list := lw.list.DeepCopyObject().(client.ObjectList)
+	if err := meta.SetList(list, objs); err != nil {
+		return nil, err
+	}
+	list.SetResourceVersion(strconv.FormatUint(revision, 10))
+	list.SetContinue("")
+	return list, nil
+}
+
+// Watch returns a watch re-listing the search every interval, the events are
+// relative to the objects of the last List or poll. The watch ends after
+// options.TimeoutSeconds if it is set.
+func (lw *ListWatch) Watch(options metav1.ListOptions) (watch.Interface, error) {
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if options.TimeoutSeconds != nil {
+		ctx, cancel = context.WithTimeout(lw.ctx, time.Duration(*options.TimeoutSeconds)*time.Second)
+	} else {
+		ctx, cancel = context.WithCancel(lw.ctx)
+	}
+
+	w := &pollWatcher{result: make(chan watch.Event), cancel: cancel}
+	go w.run(ctx, lw)
+	return w, nil
+}
+
+func (lw *ListWatch) listAll(ctx context.Context) ([]runtime.Object, error) {
+	var objs []runtime.Object
+	var continueToken string
+	for {
+		options := lw.options
+		list := lw.list.DeepCopyObject().(client.ObjectList)
+		if err := lw.client.List(ctx, list, &client.ListOptions{Raw: &options, Limit: lw.pageSize, Continue: continueToken}); err != nil {
+			return nil, err
+		}
+
+		items, err := meta.ExtractList(list)
+		if err != nil {
+			return nil, err
+		}
+		objs = append(objs, items...)
+
+		continueToken = list.GetContinue()
+		if continueToken == "" || len(items) == 0 {
+			break
+		}
+	}
+
+	// objects may move between pages while the data is changing
+	return shadow.Dedup(objs)
+}
+
+// poll re-lists the search and returns the changes since the last List,
+// the changes are recorded by commit once they are delivered.
+func (lw *ListWatch) poll(ctx context.Context) ([]watch.Event, error) {
+	objs, err := lw.listAll(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lw.lock.Lock()
+	defer lw.lock.Unlock()
+
+	var events []watch.Event
+	seen := make(map[objectKey]struct{}, len(objs))
+	for _, obj := range objs {
+		key := keyOf(obj)
+		seen[key] = struct{}{}
+
+		old, ok := lw.objects[key]
+		switch {
+		case !ok:
+			events = append(events, watch.Event{Type: watch.Added, Object: obj})
+		case resourceVersionOf(old) != resourceVersionOf(obj):
+			events = append(events, watch.Event{Type: watch.Modified, Object: obj})
+		}
+	}
+	for key, obj := range lw.objects {
+		if _, ok := seen[key]; !ok {
+			events = append(events, watch.Event{Type: watch.Deleted, Object: obj})
+		}
+	}
+	return events, nil
+}
+
+func (lw *ListWatch) commit(event watch.Event) {
+	lw.lock.Lock()
+	defer lw.lock.Unlock()
+
+	key := keyOf(event.Object)
+	if event.Type == watch.Deleted {
+		delete(lw.objects, key)
+	} else {
+		lw.objects[key] = event.Object
+	}
+}
+
+func keyOf(obj runtime.Object) objectKey {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return objectKey{}
+	}
+
+	uid := accessor.GetUID()
+	if uid == "" {
+		uid = types.UID(accessor.GetNamespace() + "/" + accessor.GetName())
+	}
+	return objectKey{cluster: shadow.ClusterOf(accessor), uid: uid}
+}
+
+func resourceVersionOf(obj runtime.Object) string {
+	accessor, err := meta.Accessor(obj)
+	if err != nil {
+		return ""
+	}
+	return accessor.GetResourceVersion()
+}
+
+type pollWatcher struct {
+	result   chan watch.Event
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+func (w *pollWatcher) Stop() {
+	w.stopOnce.Do(w.cancel)
+}
+
+func (w *pollWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *pollWatcher) run(ctx context.Context, lw *ListWatch) {
+	defer close(w.result)
+	defer w.Stop()
+
+	ticker := time.NewTicker(lw.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		events, err := lw.poll(ctx)
+		if err != nil {
+			utilruntime.HandleError(err)
+			continue
+		}
+		for _, event := range events {
+			select {
+			case <-ctx.Done():
+				return
+			case w.result <- event:
+				lw.commit(event)
+			}
+		}
+	}
+}
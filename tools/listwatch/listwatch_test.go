@@ -0,0 +1,212 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package listwatch
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+// pagingClient serves the pods with clusterpedia's offset based continue.
+type pagingClient struct {
+	client.Client
+
+	lock sync.Mutex
+	pods []corev1.Pod
+}
+
+func (c *pagingClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	options := (&client.ListOptions{}).ApplyOptions(opts)
+	offset, _ := strconv.Atoi(options.Continue)
+	end := offset + int(options.Limit)
+	if end >= len(c.pods) {
+		end = len(c.pods)
+	}
+
+	pods := list.(*corev1.PodList)
+	pods.Items = append([]corev1.Pod(nil), c.pods[offset:end]...)
+	if end < len(c.pods) {
+		pods.Continue = strconv.Itoa(end)
+	}
+	return nil
+}
+
+func (c *pagingClient) set(pods ...corev1.Pod) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pods = pods
+}
+
+func newPod(cluster, name, uid, resourceVersion string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		Name:            name,
+		UID:             types.UID(uid),
+		ResourceVersion: resourceVersion,
+		Annotations:     map[string]string{constants.ShadowAnnotationClusterName: cluster},
+	}}
+}
+
+func TestPoll(t *testing.T) {
+	c := &pagingClient{}
+	c.set(
+		newPod("cluster-01", "pod-a", "uid-1", "1"),
+		newPod("cluster-02", "pod-a", "uid-2", "1"),
+		newPod("cluster-01", "pod-b", "uid-3", "1"),
+	)
+	lw := New(c, &corev1.PodList{}, builder.ListOptionsBuilder(), Options{PageSize: 2})
+
+	list, err := lw.List(metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if items := list.(*corev1.PodList).Items; len(items) != 3 {
+		t.Fatalf("Unexpect items: %d, expect: %d", len(items), 3)
+	}
+
+	c.set(
+		newPod("cluster-01", "pod-a", "uid-1", "1"),
+		newPod("cluster-02", "pod-a", "uid-2", "2"),
+		newPod("cluster-02", "pod-c", "uid-4", "1"),
+	)
+	events, err := lw.poll(context.TODO())
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	var got []string
+	for _, event := range events {
+		lw.commit(event)
+
+		pod := event.Object.(*corev1.Pod)
+		got = append(got, string(event.Type)+" "+pod.Annotations[constants.ShadowAnnotationClusterName]+"/"+pod.Name)
+	}
+	sort.Strings(got)
+	expect := []string{"ADDED cluster-02/pod-c", "DELETED cluster-01/pod-b", "MODIFIED cluster-02/pod-a"}
+	if len(got) != len(expect) {
+		t.Fatalf("Unexpect events: %v, expect: %v", got, expect)
+	}
+	for i := range got {
+		if got[i] != expect[i] {
+			t.Errorf("Unexpect event: %s, expect: %s", got[i], expect[i])
+		}
+	}
+
+	if events, _ := lw.poll(context.TODO()); len(events) != 0 {
+		t.Errorf("Unexpect events after commit: %v", events)
+	}
+}
+
+func TestListWithContext(t *testing.T) {
+	c := &pagingClient{}
+	c.set(newPod("cluster-01", "pod-a", "uid-1", "1"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	lw := New(c, &corev1.PodList{}, builder.ListOptionsBuilder(), Options{}).WithContext(ctx)
+	if _, err := lw.List(metav1.ListOptions{}); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	cancel()
+	if _, err := lw.List(metav1.ListOptions{}); err != context.Canceled {
+		t.Errorf("Unexpect error: %v, expect: %v", err, context.Canceled)
+	}
+}
+
+func TestIndexerInformer(t *testing.T) {
+	c := &pagingClient{}
+	c.set(
+		newPod("cluster-01", "pod-a", "uid-1", "1"),
+		newPod("cluster-02", "pod-a", "uid-2", "1"),
+	)
+	lw := New(c, &corev1.PodList{}, builder.ListOptionsBuilder(), Options{Interval: 10 * time.Millisecond})
+
+	deleted := make(chan string, 2)
+	indexer, controller := NewIndexerInformer(lw, &corev1.Pod{}, 0, cache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			key, _ := KeyFunc(obj)
+			deleted <- key
+		},
+	}, nil)
+
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go controller.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, controller.HasSynced) {
+		t.Fatalf("Failed to sync informer")
+	}
+
+	if keys := indexer.ListKeys(); len(keys) != 2 {
+		t.Errorf("Unexpect keys: %v, expect pod-a of both clusters", keys)
+	}
+	obj, exists, err := Get(indexer, shadow.ClusterObjectKey{Cluster: "cluster-02", Namespace: "default", Name: "pod-a"})
+	if err != nil || !exists {
+		t.Fatalf("Unexpect pod-a of cluster-02: %v, %v", exists, err)
+	}
+	if uid := obj.(*corev1.Pod).UID; uid != "uid-2" {
+		t.Errorf("Unexpect pod-a of cluster-02: %s", uid)
+	}
+	if objs, _ := indexer.ByIndex(ClusterIndex, "cluster-02"); len(objs) != 1 {
+		t.Errorf("Unexpect objects of cluster-02: %d, expect: %d", len(objs), 1)
+	}
+
+	// pod-a is deleted from cluster-02 only
+	c.set(newPod("cluster-01", "pod-a", "uid-1", "1"))
+	select {
+	case key := <-deleted:
+		if key != "cluster-02/default/pod-a" {
+			t.Errorf("Unexpect deleted key: %s", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for %s event", watch.Deleted)
+	}
+
+	obj, exists, err = Get(indexer, shadow.ClusterObjectKey{Cluster: "cluster-01", Namespace: "default", Name: "pod-a"})
+	if err != nil || !exists || obj.(*corev1.Pod).UID != "uid-1" {
+		t.Errorf("Expect pod-a of cluster-01 is kept, got %v, %v", exists, err)
+	}
+	if keys := indexer.ListKeys(); len(keys) != 1 {
+		t.Errorf("Unexpect keys: %v", keys)
+	}
+	select {
+	case key := <-deleted:
+		t.Errorf("Unexpect deleted key: %s", key)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
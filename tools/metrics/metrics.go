@@ -0,0 +1,211 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	"github.com/clusterpedia-io/client-go/tools/requestinfo"
+)
+
+const (
+	namespace = "clusterpedia"
+	subsystem = "client"
+)
+
+var (
+	requestLabels = []string{"verb", "group", "version", "resource", "clusters", "namespaces", "orderby", "fuzzy_name"}
+	resultLabels  = append(append([]string{}, requestLabels...), "code")
+	sizeLabels    = []string{"verb", "group", "version", "resource"}
+)
+
+// Metrics are the collectors of the requests sent to clusterpedia apiserver.
+// The searches are labelled by their shape, the counts of the selected clusters
+// and namespaces are bucketed to keep the cardinality bounded.
+type Metrics struct {
+	requests     *prometheus.CounterVec
+	errors       *prometheus.CounterVec
+	duration     *prometheus.HistogramVec
+	requestSize  *prometheus.HistogramVec
+	responseSize *prometheus.HistogramVec
+}
+
+func New() *Metrics {
+	return &Metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "requests_total",
+			Help:      "Number of requests sent to clusterpedia, partitioned by search shape and status code.",
+		}, resultLabels),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_errors_total",
+			Help:      "Number of requests failed with a transport error or an error status code.",
+		}, resultLabels),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_duration_seconds",
+			Help:      "Latency of the requests sent to clusterpedia until the response headers are received.",
+			Buckets:   []float64{0.005, 0.025, 0.1, 0.25, 0.5, 1, 2, 4, 8, 15, 30, 60},
+		}, requestLabels),
+		requestSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "request_size_bytes",
+			Help:      "Size of the request bodies sent to clusterpedia.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 10),
+		}, sizeLabels),
+		responseSize: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: subsystem,
+			Name:      "response_size_bytes",
+			Help:      "Size of the response bodies received from clusterpedia.",
+			Buckets:   prometheus.ExponentialBuckets(64, 4, 12),
+		}, sizeLabels),
+	}
+}
+
+// Collectors returns the collectors of m.
+func (m *Metrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.requests, m.errors, m.duration, m.requestSize, m.responseSize}
+}
+
+// Register registers the collectors of m, such as to the registry of
+// controller-runtime sigs.k8s.io/controller-runtime/pkg/metrics.Registry.
+func (m *Metrics) Register(registerer prometheus.Registerer) error {
+	for _, c := range m.Collectors() {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WrapConfig returns a copy of cfg whose transport records the requests in m.
+// Every client of this module built from the returned config, such as
+// client.GetClient, dynamic.NewForConfig, customclient.NewForConfig and
+// clusterpediaclient.NewForConfig, is instrumented.
+func WrapConfig(cfg *rest.Config, m *Metrics) *rest.Config {
+	config := rest.CopyConfig(cfg)
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return NewRoundTripper(m, rt)
+	})
+	return config
+}
+
+// NewRoundTripper wraps rt, recording the requests in m.
+func NewRoundTripper(m *Metrics, rt http.RoundTripper) http.RoundTripper {
+	return &roundTripper{metrics: m, delegate: rt}
+}
+
+type roundTripper struct {
+	metrics  *Metrics
+	delegate http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	info := requestinfo.New(req)
+	labels := prometheus.Labels{
+		"verb":       info.Verb,
+		"group":      info.Resource.Group,
+		"version":    info.Resource.Version,
+		"resource":   info.Resource.Resource,
+		"clusters":   bucket(len(info.Clusters)),
+		"namespaces": bucket(len(info.Namespaces)),
+		"orderby":    strconv.FormatBool(info.OrderBy),
+		"fuzzy_name": strconv.FormatBool(info.FuzzyName),
+	}
+	sizeLabels := prometheus.Labels{
+		"verb":     info.Verb,
+		"group":    info.Resource.Group,
+		"version":  info.Resource.Version,
+		"resource": info.Resource.Resource,
+	}
+	if req.ContentLength > 0 {
+		rt.metrics.requestSize.With(sizeLabels).Observe(float64(req.ContentLength))
+	}
+
+	start := time.Now()
+	resp, err := rt.delegate.RoundTrip(req)
+	rt.metrics.duration.With(labels).Observe(time.Since(start).Seconds())
+
+	code := "error"
+	if err == nil {
+		code = strconv.Itoa(resp.StatusCode)
+	}
+	results := prometheus.Labels{"code": code}
+	for k, v := range labels {
+		results[k] = v
+	}
+	rt.metrics.requests.With(results).Inc()
+	if err != nil || resp.StatusCode >= http.StatusBadRequest {
+		rt.metrics.errors.With(results).Inc()
+	}
+
+	if err == nil && resp.Body != nil && info.Verb != "watch" {
+		resp.Body = &countingBody{ReadCloser: resp.Body, observer: rt.metrics.responseSize.With(sizeLabels)}
+	}
+	return resp, err
+}
+
+// bucket maps the number of selected clusters or namespaces to a bounded label,
+// zero means the search is not restricted.
+func bucket(n int) string {
+	switch {
+	case n == 0:
+		return "all"
+	case n == 1:
+		return "1"
+	case n <= 5:
+		return "2-5"
+	case n <= 20:
+		return "6-20"
+	}
+	return "20+"
+}
+
+// countingBody observes the size of the response body once it is closed,
+// the size of chunked responses is unknown until they are read.
+type countingBody struct {
+	io.ReadCloser
+	observer prometheus.Observer
+
+	size int64
+	once sync.Once
+}
+
+func (b *countingBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += int64(n)
+	return n, err
+}
+
+func (b *countingBody) Close() error {
+	b.once.Do(func() { b.observer.Observe(float64(b.size)) })
+	return b.ReadCloser.Close()
+}
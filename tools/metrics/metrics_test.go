@@ -0,0 +1,87 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/client"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+)
+
+const podList = `{"kind":"PodList","apiVersion":"v1","metadata":{},"items":[{"metadata":{"name":"pod-a","namespace":"default"}}]}`
+
+func TestWrapConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/apis/clusterpedia.io/v1beta1/resources/api/v1/pods" {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(podList))
+			return
+		}
+		http.Error(w, "not found", http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	m := New()
+	registry := prometheus.NewRegistry()
+	if err := m.Register(registry); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	config, err := client.ConfigFor(WrapConfig(&rest.Config{Host: server.URL}, m))
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	pods := dc.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"})
+	query := builder.ListOptionsBuilder().Clusters("cluster-01", "cluster-02").OrderBy("name").Options()
+	if _, err := pods.List(context.TODO(), query); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if _, err := pods.Namespace("default").Get(context.TODO(), "pod-a", metav1.GetOptions{}); err == nil {
+		t.Fatalf("Expect not found error")
+	}
+
+	listed := m.requests.WithLabelValues("list", "", "v1", "pods", "2-5", "all", "true", "false", "200")
+	if got := testutil.ToFloat64(listed); got != 1 {
+		t.Errorf("Unexpect list requests: %v, expect: %v", got, 1)
+	}
+	failed := m.errors.WithLabelValues("get", "", "v1", "pods", "all", "1", "false", "false", "404")
+	if got := testutil.ToFloat64(failed); got != 1 {
+		t.Errorf("Unexpect failed requests: %v, expect: %v", got, 1)
+	}
+	if got := testutil.CollectAndCount(m.responseSize); got != 2 {
+		t.Errorf("Unexpect response size series: %v, expect: %v", got, 2)
+	}
+	if problems, err := testutil.GatherAndLint(registry); err != nil || len(problems) != 0 {
+		t.Errorf("Unexpect lint problems: %v, %v", problems, err)
+	}
+}
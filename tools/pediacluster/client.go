@@ -0,0 +1,31 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	clusterv1alpha2client "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/typed/cluster/v1alpha2"
+)
+
+// Client provides the lifecycle helpers of PediaClusters on top of the
+// generated PediaClusterInterface.
+type Client struct {
+	clusters clusterv1alpha2client.PediaClusterInterface
+}
+
+func New(clusters clusterv1alpha2client.PediaClusterInterface) *Client {
+	return &Client{clusters: clusters}
+}
@@ -0,0 +1,280 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+const DefaultTokenExpiration = 24 * time.Hour
+
+// Credentials are the credentials of a PediaCluster to its member cluster,
+// either a bearer token or a client certificate and key.
+type Credentials struct {
+	TokenData []byte
+	CertData  []byte
+	KeyData   []byte
+}
+
+func (c *Credentials) validate() error {
+	if len(c.TokenData) == 0 && (len(c.CertData) == 0 || len(c.KeyData) == 0) {
+		return errors.New("neither token nor client certificate and key are provided")
+	}
+	return nil
+}
+
+// Validity is the period the credentials of a PediaCluster are valid in,
+// NotBefore is zero if the issue time is unknown.
+type Validity struct {
+	NotBefore time.Time
+	NotAfter  time.Time
+}
+
+// ConfigFromSpec returns the config connecting to the member cluster of a
+// PediaCluster, from its kubeconfig or from its apiserver and credentials.
+func ConfigFromSpec(spec clusterv1alpha2.ClusterSpec) (*rest.Config, error) {
+	if len(spec.Kubeconfig) != 0 {
+		return clientcmd.RESTConfigFromKubeConfig(spec.Kubeconfig)
+	}
+	if spec.APIServer == "" {
+		return nil, errors.New("neither kubeconfig nor apiserver is set")
+	}
+	return &rest.Config{
+		Host:        spec.APIServer,
+		BearerToken: string(spec.TokenData),
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   spec.CAData,
+			CertData: spec.CertData,
+			KeyData:  spec.KeyData,
+		},
+	}, nil
+}
+
+// CredentialValidity returns the validity of the credentials of a PediaCluster,
+// it ends at the earliest of the exp claim of a JWT token and the NotAfter of
+// the client certificate. The validity is nil if the credentials do not expire,
+// such as the token of a legacy ServiceAccount token Secret.
+func CredentialValidity(spec clusterv1alpha2.ClusterSpec) (*Validity, error) {
+	config, err := ConfigFromSpec(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	var validity *Validity
+	earliest := func(v *Validity) {
+		if v != nil && (validity == nil || v.NotAfter.Before(validity.NotAfter)) {
+			validity = v
+		}
+	}
+
+	if config.BearerToken != "" {
+		v, err := tokenValidity(config.BearerToken)
+		if err != nil {
+			return nil, err
+		}
+		earliest(v)
+	}
+	if len(config.CertData) != 0 {
+		v, err := certificateValidity(config.CertData)
+		if err != nil {
+			return nil, err
+		}
+		earliest(v)
+	}
+	return validity, nil
+}
+
+// tokenValidity reads the exp and iat claims of a JWT token without verifying
+// it, an opaque token does not expire.
+func tokenValidity(token string) (*Validity, error) {
+	parts := strings.Split(strings.TrimSpace(token), ".")
+	if len(parts) != 3 {
+		return nil, nil
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode the payload of the token: %w", err)
+	}
+	var claims struct {
+		Exp *float64 `json:"exp"`
+		Iat *float64 `json:"iat"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, fmt.Errorf("failed to decode the claims of the token: %w", err)
+	}
+	if claims.Exp == nil {
+		return nil, nil
+	}
+
+	validity := &Validity{NotAfter: time.Unix(int64(*claims.Exp), 0)}
+	if claims.Iat != nil {
+		validity.NotBefore = time.Unix(int64(*claims.Iat), 0)
+	}
+	return validity, nil
+}
+
+// certificateValidity returns the validity of the first certificate of the PEM data.
+func certificateValidity(data []byte) (*Validity, error) {
+	for {
+		var block *pem.Block
+		block, data = pem.Decode(data)
+		if block == nil {
+			return nil, errors.New("no certificate is found in the client certificate data")
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse the client certificate: %w", err)
+		}
+		return &Validity{NotBefore: cert.NotBefore, NotAfter: cert.NotAfter}, nil
+	}
+}
+
+// CredentialProvider issues fresh credentials for the member cluster of a PediaCluster.
+type CredentialProvider interface {
+	Credentials(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error)
+}
+
+// CredentialProviderFunc is a function implementing CredentialProvider.
+type CredentialProviderFunc func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error)
+
+func (fn CredentialProviderFunc) Credentials(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+	return fn(ctx, cluster)
+}
+
+// TokenRequestProvider requests a bound token of a ServiceAccount in the member
+// cluster with the current credentials of the PediaCluster, such as the
+// ServiceAccount created by Register. The current credentials must still be valid.
+//
+// The token registered by Register is read from a legacy ServiceAccount token
+// Secret and has no exp claim, so the Rotator leaves such a PediaCluster alone
+// until its credentials are replaced by expiring ones.
+type TokenRequestProvider struct {
+	// Namespace and Name of the ServiceAccount, default to
+	// DefaultServiceAccountNamespace and DefaultServiceAccountName.
+	Namespace string
+	Name      string
+
+	// Expiration is the requested lifetime of the token, defaults to DefaultTokenExpiration.
+	Expiration time.Duration
+
+	// NewClient creates the client of the member cluster, defaults to kubernetes.NewForConfig.
+	NewClient func(config *rest.Config) (kubernetes.Interface, error)
+}
+
+var _ CredentialProvider = &TokenRequestProvider{}
+
+func (p *TokenRequestProvider) Credentials(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+	namespace, name, expiration := p.Namespace, p.Name, p.Expiration
+	if namespace == "" {
+		namespace = DefaultServiceAccountNamespace
+	}
+	if name == "" {
+		name = DefaultServiceAccountName
+	}
+	if expiration <= 0 {
+		expiration = DefaultTokenExpiration
+	}
+
+	config, err := ConfigFromSpec(cluster.Spec)
+	if err != nil {
+		return nil, err
+	}
+	newClient := p.NewClient
+	if newClient == nil {
+		newClient = func(config *rest.Config) (kubernetes.Interface, error) {
+			return kubernetes.NewForConfig(config)
+		}
+	}
+	cs, err := newClient(config)
+	if err != nil {
+		return nil, err
+	}
+
+	expirationSeconds := int64(expiration / time.Second)
+	request := &authenticationv1.TokenRequest{
+		Spec: authenticationv1.TokenRequestSpec{ExpirationSeconds: &expirationSeconds},
+	}
+	request, err = cs.CoreV1().ServiceAccounts(namespace).CreateToken(ctx, name, request, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to request the token of ServiceAccount %s/%s: %w", namespace, name, err)
+	}
+	return &Credentials{TokenData: []byte(request.Status.Token)}, nil
+}
+
+// applyCredentials returns the spec fields of the PediaCluster holding creds,
+// the credentials of the current context are replaced in a kubeconfig. The
+// credentials of the kind not provided are removed, so that an expiring
+// certificate is not kept beside a fresh token.
+func applyCredentials(spec clusterv1alpha2.ClusterSpec, creds *Credentials) (map[string]interface{}, error) {
+	hasToken, hasCert := len(creds.TokenData) != 0, len(creds.CertData) != 0
+	if len(spec.Kubeconfig) == 0 {
+		fields := map[string]interface{}{"tokenData": nil, "certData": nil, "keyData": nil}
+		if hasToken {
+			fields["tokenData"] = creds.TokenData
+		}
+		if hasCert {
+			fields["certData"] = creds.CertData
+			fields["keyData"] = creds.KeyData
+		}
+		return fields, nil
+	}
+
+	config, err := clientcmd.Load(spec.Kubeconfig)
+	if err != nil {
+		return nil, err
+	}
+	kubeContext, ok := config.Contexts[config.CurrentContext]
+	if !ok {
+		return nil, fmt.Errorf("current context %q is not found in kubeconfig", config.CurrentContext)
+	}
+	authInfo, ok := config.AuthInfos[kubeContext.AuthInfo]
+	if !ok {
+		return nil, fmt.Errorf("user %q of context %q is not found in kubeconfig", kubeContext.AuthInfo, config.CurrentContext)
+	}
+	authInfo.Token, authInfo.TokenFile = string(creds.TokenData), ""
+	authInfo.ClientCertificate, authInfo.ClientKey = "", ""
+	authInfo.ClientCertificateData, authInfo.ClientKeyData = nil, nil
+	if hasCert {
+		authInfo.ClientCertificateData, authInfo.ClientKeyData = creds.CertData, creds.KeyData
+	}
+
+	kubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"kubeconfig": kubeconfig}, nil
+}
@@ -0,0 +1,155 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ClusterHealth is the summary of the status of a PediaCluster.
+type ClusterHealth struct {
+	Name      string `json:"name"`
+	APIServer string `json:"apiserver,omitempty"`
+	Version   string `json:"version,omitempty"`
+
+	Ready     bool `json:"ready"`
+	Validated bool `json:"validated"`
+	Running   bool `json:"running"`
+	Reachable bool `json:"reachable"`
+
+	// Reason and Message explain the first condition that is not true.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+
+	// StaleSince is when the cluster became unreachable or not ready, the data of
+	// the cluster in clusterpedia is not updated since then.
+	StaleSince *metav1.Time `json:"staleSince,omitempty"`
+
+	// The resources are counted per version, a resource synchronized in two
+	// versions is counted twice.
+	Versions        int `json:"versions"`
+	SyncedVersions  int `json:"syncedVersions"`
+	PendingVersions int `json:"pendingVersions"`
+
+	// StoppedVersions are the versions whose synchronization is stopped on
+	// purpose, they are not failures.
+	StoppedVersions int `json:"stoppedVersions"`
+
+	FailedVersions []ResourceHealth `json:"failedVersions,omitempty"`
+}
+
+// ResourceHealth is the sync status of a version of a resource that failed to
+// be synchronized.
+type ResourceHealth struct {
+	Resource schema.GroupVersionResource `json:"resource"`
+	Kind     string                      `json:"kind"`
+	Status   string                      `json:"status"`
+	Reason   string                      `json:"reason,omitempty"`
+	Message  string                      `json:"message,omitempty"`
+	Since    metav1.Time                 `json:"since"`
+}
+
+// Summarize interprets the conditions and the resource sync status of the cluster.
+func Summarize(cluster *clusterv1alpha2.PediaCluster) ClusterHealth {
+	conditions := cluster.Status.Conditions
+	health := ClusterHealth{
+		Name:      cluster.Name,
+		APIServer: cluster.Status.APIServer,
+		Version:   cluster.Status.Version,
+		Ready:     meta.IsStatusConditionTrue(conditions, clusterv1alpha2.ReadyCondition),
+		Validated: meta.IsStatusConditionTrue(conditions, clusterv1alpha2.ValidatedCondition),
+		Running:   meta.IsStatusConditionTrue(conditions, clusterv1alpha2.SynchroRunningCondition),
+		Reachable: meta.IsStatusConditionTrue(conditions, clusterv1alpha2.ClusterHealthyCondition),
+	}
+
+	for _, conditionType := range []string{
+		clusterv1alpha2.ValidatedCondition,
+		clusterv1alpha2.SynchroRunningCondition,
+		clusterv1alpha2.ClusterHealthyCondition,
+		clusterv1alpha2.ReadyCondition,
+	} {
+		cond := meta.FindStatusCondition(conditions, conditionType)
+		if cond != nil && cond.Status != metav1.ConditionTrue {
+			health.Reason, health.Message = cond.Reason, cond.Message
+			break
+		}
+	}
+
+	for _, conditionType := range []string{clusterv1alpha2.ClusterHealthyCondition, clusterv1alpha2.ReadyCondition} {
+		cond := meta.FindStatusCondition(conditions, conditionType)
+		if cond != nil && cond.Status != metav1.ConditionTrue {
+			since := cond.LastTransitionTime
+			health.StaleSince = &since
+			break
+		}
+	}
+
+	for _, group := range cluster.Status.SyncResources {
+		for _, resource := range group.Resources {
+			for _, cond := range resource.SyncConditions {
+				health.Versions++
+				switch cond.Status {
+				case clusterv1alpha2.ResourceSyncStatusSyncing:
+					health.SyncedVersions++
+				case clusterv1alpha2.ResourceSyncStatusPending:
+					health.PendingVersions++
+				case clusterv1alpha2.ResourceSyncStatusStop:
+					health.StoppedVersions++
+				default:
+					health.FailedVersions = append(health.FailedVersions, ResourceHealth{
+						Resource: schema.GroupVersionResource{Group: group.Group, Version: cond.Version, Resource: resource.Name},
+						Kind:     resource.Kind,
+						Status:   cond.Status,
+						Reason:   cond.Reason,
+						Message:  cond.Message,
+						Since:    cond.LastTransitionTime,
+					})
+				}
+			}
+		}
+	}
+	return health
+}
+
+// Health returns the summary of the status of the PediaCluster.
+func (c *Client) Health(ctx context.Context, name string) (*ClusterHealth, error) {
+	cluster, err := c.clusters.Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	health := Summarize(cluster)
+	return &health, nil
+}
+
+// HealthAll returns the summaries of the PediaClusters selected by opts.
+func (c *Client) HealthAll(ctx context.Context, opts metav1.ListOptions) ([]ClusterHealth, error) {
+	clusters, err := c.clusters.List(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	healths := make([]ClusterHealth, 0, len(clusters.Items))
+	for i := range clusters.Items {
+		healths = append(healths, Summarize(&clusters.Items[i]))
+	}
+	return healths, nil
+}
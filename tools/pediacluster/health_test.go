@@ -0,0 +1,93 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"testing"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	pediafake "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+func TestSummarize(t *testing.T) {
+	cluster := newCluster(false, clusterv1alpha2.ResourceSyncStatusSyncing)
+	cluster.Status.SyncResources = append(cluster.Status.SyncResources, clusterv1alpha2.ClusterGroupResourcesStatus{
+		Resources: []clusterv1alpha2.ClusterResourceStatus{{
+			Name: "pods",
+			Kind: "Pod",
+			SyncConditions: []clusterv1alpha2.ClusterResourceSyncCondition{
+				{Version: "v1", Status: clusterv1alpha2.ResourceSyncStatusError, Reason: "WatchError", Message: "forbidden"},
+			},
+		}, {
+			Name: "configmaps",
+			Kind: "ConfigMap",
+			SyncConditions: []clusterv1alpha2.ClusterResourceSyncCondition{
+				{Version: "v1", Status: clusterv1alpha2.ResourceSyncStatusPending},
+			},
+		}, {
+			Name: "secrets",
+			Kind: "Secret",
+			SyncConditions: []clusterv1alpha2.ClusterResourceSyncCondition{
+				{Version: "v1", Status: clusterv1alpha2.ResourceSyncStatusStop, Reason: "Stopped"},
+			},
+		}},
+	})
+	since := metav1.Now()
+	cluster.Status.Conditions[1].LastTransitionTime = since
+
+	health := Summarize(cluster)
+	if health.Ready || health.Reachable || !health.Validated {
+		t.Errorf("Unexpect conditions: %+v", health)
+	}
+	if health.Reason != clusterv1alpha2.ClusterNotReachableReason || health.StaleSince == nil || !health.StaleSince.Equal(&since) {
+		t.Errorf("Unexpect reason or stale since: %s, %v", health.Reason, health.StaleSince)
+	}
+	if health.Versions != 4 || health.SyncedVersions != 1 || health.PendingVersions != 1 || health.StoppedVersions != 1 || len(health.FailedVersions) != 1 {
+		t.Fatalf("Unexpect resource counts: %+v", health)
+	}
+	if failed := health.FailedVersions[0]; failed.Resource != (schema.GroupVersionResource{Version: "v1", Resource: "pods"}) || failed.Message != "forbidden" {
+		t.Errorf("Unexpect failed resource: %+v", failed)
+	}
+
+	healthy := Summarize(newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing))
+	if !healthy.Ready || healthy.StaleSince != nil || healthy.Reason != "" {
+		t.Errorf("Unexpect healthy cluster summary: %+v", healthy)
+	}
+}
+
+func TestHealthAll(t *testing.T) {
+	ready := newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing)
+	ready.Name = "ready"
+	cs := pediafake.NewSimpleClientset(ready, newCluster(false, clusterv1alpha2.ResourceSyncStatusPending))
+
+	healths, err := New(cs.ClusterV1alpha2().PediaClusters()).HealthAll(context.TODO(), metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if len(healths) != 2 {
+		t.Fatalf("Unexpect healths: %d, expect: %d", len(healths), 2)
+	}
+	for _, health := range healths {
+		if health.Ready != (health.Name == "ready") {
+			t.Errorf("Unexpect health of %s: %+v", health.Name, health)
+		}
+	}
+}
@@ -0,0 +1,282 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	clientretry "k8s.io/client-go/util/retry"
+
+	clusterv1alpha2client "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/typed/cluster/v1alpha2"
+)
+
+const (
+	DefaultServiceAccountNamespace = "kube-system"
+	DefaultServiceAccountName      = "clusterpedia-synchro"
+	DefaultClusterRoleName         = "clusterpedia-synchro"
+	DefaultTokenTimeout            = 30 * time.Second
+)
+
+type RegisterOptions struct {
+	// Kubeconfig is the path of the kubeconfig of the member cluster,
+	// defaults to the default loading rules of kubectl.
+	Kubeconfig string
+
+	// Context is the context of the member cluster, defaults to the current context.
+	Context string
+
+	// ServiceAccount creates a dedicated ServiceAccount in the member cluster
+	// and registers its token instead of the credentials of the kubeconfig.
+	ServiceAccount *ServiceAccountOptions
+
+	// Labels are added to the labels of the PediaCluster.
+	Labels map[string]string
+
+	// SyncResources and SyncAllCustomResources are set when the PediaCluster is
+	// created, an existing PediaCluster keeps its resources unless SyncResources is set.
+	SyncResources          []clusterv1alpha2.ClusterGroupResources
+	SyncAllCustomResources bool
+}
+
+type ServiceAccountOptions struct {
+	// Namespace and Name of the ServiceAccount, default to
+	// DefaultServiceAccountNamespace and DefaultServiceAccountName.
+	Namespace string
+	Name      string
+
+	// ClusterRole is bound to the ServiceAccount, defaults to DefaultClusterRoleName
+	// which is created with the permission to read all resources.
+	ClusterRole string
+
+	// Timeout bounds the wait for the token of the ServiceAccount, defaults to DefaultTokenTimeout.
+	Timeout time.Duration
+}
+
+func (opts *ServiceAccountOptions) complete() {
+	if opts.Namespace == "" {
+		opts.Namespace = DefaultServiceAccountNamespace
+	}
+	if opts.Name == "" {
+		opts.Name = DefaultServiceAccountName
+	}
+	if opts.ClusterRole == "" {
+		opts.ClusterRole = DefaultClusterRoleName
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = DefaultTokenTimeout
+	}
+}
+
+// Register creates the PediaCluster name from a kubeconfig context of the member
+// cluster, or updates its credentials if it exists. Registering a cluster again
+// with the same credentials does not modify the PediaCluster.
+func Register(ctx context.Context, clusters clusterv1alpha2client.PediaClusterInterface, name string, opts RegisterOptions) (*clusterv1alpha2.PediaCluster, error) {
+	config, err := ConfigFromKubeconfig(opts.Kubeconfig, opts.Context)
+	if err != nil {
+		return nil, err
+	}
+
+	var spec clusterv1alpha2.ClusterSpec
+	if opts.ServiceAccount != nil {
+		cs, err := kubernetes.NewForConfig(config)
+		if err != nil {
+			return nil, err
+		}
+		token, err := ServiceAccountToken(ctx, cs, *opts.ServiceAccount)
+		if err != nil {
+			return nil, err
+		}
+
+		tlsConfig := rest.CopyConfig(config)
+		if err := rest.LoadTLSFiles(tlsConfig); err != nil {
+			return nil, err
+		}
+		spec = clusterv1alpha2.ClusterSpec{APIServer: config.Host, CAData: tlsConfig.CAData, TokenData: token}
+	} else if spec, err = SpecFromConfig(config); err != nil {
+		return nil, err
+	}
+
+	var cluster *clusterv1alpha2.PediaCluster
+	err = clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		current, err := clusters.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			spec.SyncResources = opts.SyncResources
+			spec.SyncAllCustomResources = opts.SyncAllCustomResources
+			if spec.SyncResources == nil {
+				spec.SyncResources = []clusterv1alpha2.ClusterGroupResources{}
+			}
+			cluster, err = clusters.Create(ctx, &clusterv1alpha2.PediaCluster{
+				ObjectMeta: metav1.ObjectMeta{Name: name, Labels: opts.Labels},
+				Spec:       spec,
+			}, metav1.CreateOptions{})
+			return err
+		}
+		if err != nil {
+			return err
+		}
+
+		updated := current.DeepCopy()
+		updated.Spec.Kubeconfig = nil
+		updated.Spec.APIServer = spec.APIServer
+		updated.Spec.CAData = spec.CAData
+		updated.Spec.TokenData = spec.TokenData
+		updated.Spec.CertData = spec.CertData
+		updated.Spec.KeyData = spec.KeyData
+		if opts.SyncResources != nil {
+			updated.Spec.SyncResources = opts.SyncResources
+			updated.Spec.SyncAllCustomResources = opts.SyncAllCustomResources
+		}
+		for key, value := range opts.Labels {
+			if updated.Labels == nil {
+				updated.Labels = make(map[string]string, len(opts.Labels))
+			}
+			updated.Labels[key] = value
+		}
+		if reflect.DeepEqual(current.Spec, updated.Spec) && reflect.DeepEqual(current.Labels, updated.Labels) {
+			cluster = current
+			return nil
+		}
+
+		cluster, err = clusters.Update(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// ConfigFromKubeconfig returns the config of a context of the kubeconfig file,
+// the default loading rules of kubectl are used if kubeconfig is empty.
+func ConfigFromKubeconfig(kubeconfig, context string) (*rest.Config, error) {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	if kubeconfig != "" {
+		loadingRules.ExplicitPath = kubeconfig
+	}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, &clientcmd.ConfigOverrides{CurrentContext: context}).ClientConfig()
+}
+
+// SpecFromConfig returns the connection of a PediaCluster to the member cluster
+// of config, the files referenced by config are read into the spec.
+func SpecFromConfig(config *rest.Config) (clusterv1alpha2.ClusterSpec, error) {
+	config = rest.CopyConfig(config)
+	if err := rest.LoadTLSFiles(config); err != nil {
+		return clusterv1alpha2.ClusterSpec{}, err
+	}
+
+	spec := clusterv1alpha2.ClusterSpec{
+		APIServer: config.Host,
+		CAData:    config.CAData,
+		CertData:  config.CertData,
+		KeyData:   config.KeyData,
+	}
+	if config.BearerToken != "" {
+		spec.TokenData = []byte(config.BearerToken)
+	} else if config.BearerTokenFile != "" {
+		token, err := os.ReadFile(config.BearerTokenFile)
+		if err != nil {
+			return clusterv1alpha2.ClusterSpec{}, err
+		}
+		spec.TokenData = token
+	}
+
+	if spec.APIServer == "" {
+		return clusterv1alpha2.ClusterSpec{}, errors.New("apiserver of the member cluster is empty")
+	}
+	if len(spec.TokenData) == 0 && (len(spec.CertData) == 0 || len(spec.KeyData) == 0) {
+		if config.ExecProvider != nil || config.AuthProvider != nil {
+			return clusterv1alpha2.ClusterSpec{}, errors.New("credentials of exec and auth providers can not be registered, use a ServiceAccount instead")
+		}
+		return clusterv1alpha2.ClusterSpec{}, errors.New("neither token nor client certificate is found for the member cluster")
+	}
+	return spec, nil
+}
+
+// ServiceAccountToken creates a ServiceAccount bound to the ClusterRole in the
+// member cluster, and returns the token of its long-lived token Secret.
+// The existing objects are reused.
+func ServiceAccountToken(ctx context.Context, cs kubernetes.Interface, opts ServiceAccountOptions) ([]byte, error) {
+	opts.complete()
+
+	serviceAccount := &corev1.ServiceAccount{ObjectMeta: metav1.ObjectMeta{Namespace: opts.Namespace, Name: opts.Name}}
+	if _, err := cs.CoreV1().ServiceAccounts(opts.Namespace).Create(ctx, serviceAccount, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create ServiceAccount %s/%s: %w", opts.Namespace, opts.Name, err)
+	}
+
+	if opts.ClusterRole == DefaultClusterRoleName {
+		role := &rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{Name: DefaultClusterRoleName},
+			Rules: []rbacv1.PolicyRule{
+				{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"get", "list", "watch"}},
+				{NonResourceURLs: []string{"*"}, Verbs: []string{"get"}},
+			},
+		}
+		if _, err := cs.RbacV1().ClusterRoles().Create(ctx, role, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+			return nil, fmt.Errorf("failed to create ClusterRole %s: %w", role.Name, err)
+		}
+	}
+
+	binding := &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: opts.Name},
+		RoleRef:    rbacv1.RoleRef{APIGroup: rbacv1.GroupName, Kind: "ClusterRole", Name: opts.ClusterRole},
+		Subjects:   []rbacv1.Subject{{Kind: rbacv1.ServiceAccountKind, Namespace: opts.Namespace, Name: opts.Name}},
+	}
+	if _, err := cs.RbacV1().ClusterRoleBindings().Create(ctx, binding, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create ClusterRoleBinding %s: %w", binding.Name, err)
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace:   opts.Namespace,
+			Name:        opts.Name + "-token",
+			Annotations: map[string]string{corev1.ServiceAccountNameKey: opts.Name},
+		},
+		Type: corev1.SecretTypeServiceAccountToken,
+	}
+	if _, err := cs.CoreV1().Secrets(opts.Namespace).Create(ctx, secret, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, fmt.Errorf("failed to create token Secret %s/%s: %w", secret.Namespace, secret.Name, err)
+	}
+
+	// the token is populated by the token controller of the member cluster
+	var token []byte
+	err := wait.PollUntilContextTimeout(ctx, time.Second, opts.Timeout, true, func(ctx context.Context) (bool, error) {
+		secret, err := cs.CoreV1().Secrets(opts.Namespace).Get(ctx, opts.Name+"-token", metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		token = secret.Data[corev1.ServiceAccountTokenKey]
+		return len(token) != 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to wait for the token of ServiceAccount %s/%s: %w", opts.Namespace, opts.Name, err)
+	}
+	return token, nil
+}
@@ -0,0 +1,128 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	pediafake "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+const kubeconfig = `apiVersion: v1
+kind: Config
+clusters:
+- name: member
+  cluster:
+    server: https://member:6443
+    certificate-authority: ca.crt
+users:
+- name: admin
+  user:
+    tokenFile: token
+contexts:
+- name: member
+  context:
+    cluster: member
+    user: admin
+current-context: member
+`
+
+func writeKubeconfig(t *testing.T, token string) string {
+	dir := t.TempDir()
+	for name, data := range map[string]string{"config": kubeconfig, "ca.crt": "ca-data", "token": token} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(data), 0600); err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+	}
+	return filepath.Join(dir, "config")
+}
+
+func TestRegister(t *testing.T) {
+	cs := pediafake.NewSimpleClientset()
+	clusters := cs.ClusterV1alpha2().PediaClusters()
+	opts := RegisterOptions{
+		Kubeconfig: writeKubeconfig(t, "token-1"),
+		Labels:     map[string]string{"env": "test"},
+	}
+
+	cluster, err := Register(context.TODO(), clusters, "member", opts)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if cluster.Spec.APIServer != "https://member:6443" || string(cluster.Spec.CAData) != "ca-data" || string(cluster.Spec.TokenData) != "token-1" {
+		t.Errorf("Unexpect spec: %+v", cluster.Spec)
+	}
+	if cluster.Spec.SyncResources == nil || cluster.Labels["env"] != "test" {
+		t.Errorf("Unexpect cluster: %+v", cluster)
+	}
+
+	if _, err := Register(context.TODO(), clusters, "member", opts); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if updates := countActions(cs, "update"); updates != 0 {
+		t.Errorf("Unexpect updates of registering again: %d", updates)
+	}
+
+	cluster.Spec.SyncResources = []clusterv1alpha2.ClusterGroupResources{{Group: "apps", Resources: []string{"deployments"}}}
+	if err := cs.Tracker().Update(pediaClustersResource, cluster, ""); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	opts.Kubeconfig = writeKubeconfig(t, "token-2")
+	if cluster, err = Register(context.TODO(), clusters, "member", opts); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if countActions(cs, "update") != 1 || string(cluster.Spec.TokenData) != "token-2" || len(cluster.Spec.SyncResources) != 1 {
+		t.Errorf("Unexpect updated cluster: %+v", cluster.Spec)
+	}
+}
+
+func TestServiceAccountToken(t *testing.T) {
+	cs := fake.NewSimpleClientset()
+	cs.PrependReactor("create", "secrets", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		secret := action.(clienttesting.CreateAction).GetObject().(*corev1.Secret)
+		secret.Data = map[string][]byte{corev1.ServiceAccountTokenKey: []byte("sa-token")}
+		return false, nil, nil
+	})
+
+	for i := 0; i < 2; i++ {
+		token, err := ServiceAccountToken(context.TODO(), cs, ServiceAccountOptions{})
+		if err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+		if string(token) != "sa-token" {
+			t.Errorf("Unexpect token: %s", token)
+		}
+	}
+
+	binding, err := cs.RbacV1().ClusterRoleBindings().Get(context.TODO(), DefaultServiceAccountName, metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if binding.RoleRef.Name != DefaultClusterRoleName || binding.Subjects[0].Namespace != DefaultServiceAccountNamespace {
+		t.Errorf("Unexpect binding: %+v", binding)
+	}
+}
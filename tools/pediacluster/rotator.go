@@ -0,0 +1,297 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/record"
+	"k8s.io/utils/clock"
+
+	clusterv1alpha2client "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/typed/cluster/v1alpha2"
+)
+
+const (
+	DefaultRotationInterval = time.Minute
+	DefaultRenewBefore      = 10 * time.Minute
+
+	// ReasonCredentialsRotated is the reason of the Normal event of a rotation.
+	ReasonCredentialsRotated = "CredentialsRotated"
+
+	// ReasonCredentialRotationFailed is the reason of the Warning event of a failed rotation.
+	ReasonCredentialRotationFailed = "CredentialRotationFailed"
+)
+
+type RotatorOptions struct {
+	// Provider issues the fresh credentials, such as a TokenRequestProvider.
+	Provider CredentialProvider
+
+	// RenewBefore is how long before the expiry the credentials are rotated,
+	// defaults to a fifth of the lifetime of the credentials, or to
+	// DefaultRenewBefore if their issue time is unknown.
+	RenewBefore time.Duration
+
+	// Interval is the period Run checks the clusters at, defaults to DefaultRotationInterval.
+	Interval time.Duration
+
+	// Recorder records the rotations as events of the PediaClusters, no event
+	// is recorded if it is nil. The scheme of the recorder must register
+	// clusterv1alpha2, such as with clusterv1alpha2.AddToScheme, or the
+	// events are dropped since their PediaCluster cannot be referenced.
+	Recorder record.EventRecorder
+
+	// Metrics records the rotations and the expiry of the credentials.
+	Metrics *RotationMetrics
+
+	Clock clock.PassiveClock
+}
+
+// Rotator replaces the credentials of PediaClusters before they expire, so
+// that the synchronization of the member clusters does not stop silently.
+type Rotator struct {
+	clusters    clusterv1alpha2client.PediaClusterInterface
+	provider    CredentialProvider
+	renewBefore time.Duration
+	interval    time.Duration
+	recorder    record.EventRecorder
+	metrics     *RotationMetrics
+	clock       clock.PassiveClock
+}
+
+func NewRotator(clusters clusterv1alpha2client.PediaClusterInterface, opts RotatorOptions) (*Rotator, error) {
+	if opts.Provider == nil {
+		return nil, errors.New("credential provider is required")
+	}
+
+	r := &Rotator{
+		clusters:    clusters,
+		provider:    opts.Provider,
+		renewBefore: opts.RenewBefore,
+		interval:    opts.Interval,
+		recorder:    opts.Recorder,
+		metrics:     opts.Metrics,
+		clock:       opts.Clock,
+	}
+	if r.interval <= 0 {
+		r.interval = DefaultRotationInterval
+	}
+	if r.clock == nil {
+		r.clock = clock.RealClock{}
+	}
+	return r, nil
+}
+
+// Run rotates the credentials of all PediaClusters every interval until ctx is done.
+func (r *Rotator) Run(ctx context.Context) {
+	wait.UntilWithContext(ctx, func(ctx context.Context) {
+		if err := r.RotateAll(ctx); err != nil {
+			utilruntime.HandleError(err)
+		}
+	}, r.interval)
+}
+
+// RotateAll rotates the credentials of the PediaClusters expiring soon, the
+// failures of the clusters are aggregated.
+func (r *Rotator) RotateAll(ctx context.Context) error {
+	clusters, err := r.clusters.List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return err
+	}
+
+	var errs []error
+	names := sets.New[string]()
+	for i := range clusters.Items {
+		cluster := &clusters.Items[i]
+		names.Insert(cluster.Name)
+		if _, err := r.Rotate(ctx, cluster); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	r.metrics.retain(names)
+	return utilerrors.NewAggregate(errs)
+}
+
+// Rotate replaces the credentials of the PediaCluster if they expire soon, and
+// returns true if they are replaced. The PediaCluster is patched with its
+// resourceVersion as precondition, a concurrent change fails the rotation.
+func (r *Rotator) Rotate(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (bool, error) {
+	validity, err := CredentialValidity(cluster.Spec)
+	if err != nil {
+		return false, r.failed(cluster, fmt.Errorf("failed to read the credentials: %w", err))
+	}
+	r.metrics.observeExpiry(cluster.Name, validity)
+	if validity == nil || r.clock.Now().Before(r.renewAt(validity)) {
+		return false, nil
+	}
+
+	creds, err := r.provider.Credentials(ctx, cluster)
+	if err != nil {
+		return false, r.failed(cluster, fmt.Errorf("failed to get fresh credentials: %w", err))
+	}
+	if err := creds.validate(); err != nil {
+		return false, r.failed(cluster, err)
+	}
+
+	fields, err := applyCredentials(cluster.Spec, creds)
+	if err != nil {
+		return false, r.failed(cluster, err)
+	}
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{"resourceVersion": cluster.ResourceVersion},
+		"spec":     fields,
+	})
+	if err != nil {
+		return false, r.failed(cluster, err)
+	}
+	rotated, err := r.clusters.Patch(ctx, cluster.Name, types.MergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return false, r.failed(cluster, fmt.Errorf("failed to patch the credentials: %w", err))
+	}
+
+	newValidity, _ := CredentialValidity(rotated.Spec)
+	r.metrics.observeExpiry(cluster.Name, newValidity)
+	if newValidity != nil && !r.clock.Now().Before(r.renewAt(newValidity)) {
+		return false, r.failed(rotated, fmt.Errorf("the new credentials expire at %s, they are still due for renewal",
+			newValidity.NotAfter.UTC().Format(time.RFC3339)))
+	}
+	r.metrics.rotated(cluster.Name, true)
+
+	message := fmt.Sprintf("Rotated the credentials expiring at %s", validity.NotAfter.UTC().Format(time.RFC3339))
+	if newValidity != nil {
+		message += fmt.Sprintf(", the new credentials expire at %s", newValidity.NotAfter.UTC().Format(time.RFC3339))
+	}
+	r.event(cluster, corev1.EventTypeNormal, ReasonCredentialsRotated, message)
+	return true, nil
+}
+
+// renewAt returns when the credentials of validity are rotated.
+func (r *Rotator) renewAt(validity *Validity) time.Time {
+	renewBefore := r.renewBefore
+	if renewBefore <= 0 {
+		renewBefore = DefaultRenewBefore
+		if !validity.NotBefore.IsZero() && validity.NotAfter.After(validity.NotBefore) {
+			renewBefore = validity.NotAfter.Sub(validity.NotBefore) / 5
+		}
+	}
+	return validity.NotAfter.Add(-renewBefore)
+}
+
+func (r *Rotator) failed(cluster *clusterv1alpha2.PediaCluster, err error) error {
+	r.metrics.rotated(cluster.Name, false)
+	r.event(cluster, corev1.EventTypeWarning, ReasonCredentialRotationFailed, err.Error())
+	return fmt.Errorf("failed to rotate the credentials of PediaCluster %s: %w", cluster.Name, err)
+}
+
+func (r *Rotator) event(cluster *clusterv1alpha2.PediaCluster, eventType, reason, message string) {
+	if r.recorder != nil {
+		r.recorder.Event(cluster, eventType, reason, message)
+	}
+}
+
+// RotationMetrics are the collectors of the credential rotations.
+type RotationMetrics struct {
+	rotations *prometheus.CounterVec
+	expiry    *prometheus.GaugeVec
+
+	lock     sync.Mutex
+	clusters sets.Set[string]
+}
+
+func NewRotationMetrics() *RotationMetrics {
+	return &RotationMetrics{
+		rotations: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "clusterpedia",
+			Subsystem: "pediacluster",
+			Name:      "credential_rotations_total",
+			Help:      "Number of credential rotations of PediaClusters, partitioned by cluster and result.",
+		}, []string{"cluster", "result"}),
+		expiry: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: "clusterpedia",
+			Subsystem: "pediacluster",
+			Name:      "credential_expiry_timestamp_seconds",
+			Help:      "Unix time the credentials of a PediaCluster expire at, absent if they do not expire.",
+		}, []string{"cluster"}),
+		clusters: sets.New[string](),
+	}
+}
+
+// Collectors returns the collectors of m.
+func (m *RotationMetrics) Collectors() []prometheus.Collector {
+	return []prometheus.Collector{m.rotations, m.expiry}
+}
+
+// Register registers the collectors of m, such as to the registry of
+// controller-runtime sigs.k8s.io/controller-runtime/pkg/metrics.Registry.
+func (m *RotationMetrics) Register(registerer prometheus.Registerer) error {
+	for _, c := range m.Collectors() {
+		if err := registerer.Register(c); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *RotationMetrics) rotated(cluster string, succeeded bool) {
+	if m == nil {
+		return
+	}
+	result := "success"
+	if !succeeded {
+		result = "failure"
+	}
+	m.rotations.WithLabelValues(cluster, result).Inc()
+}
+
+func (m *RotationMetrics) observeExpiry(cluster string, validity *Validity) {
+	if m == nil {
+		return
+	}
+	if validity == nil {
+		m.expiry.DeleteLabelValues(cluster)
+		return
+	}
+	m.expiry.WithLabelValues(cluster).Set(float64(validity.NotAfter.Unix()))
+}
+
+// retain drops the series of the clusters that are deleted.
+func (m *RotationMetrics) retain(clusters sets.Set[string]) {
+	if m == nil {
+		return
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	for cluster := range m.clusters.Difference(clusters) {
+		m.rotations.DeletePartialMatch(prometheus.Labels{"cluster": cluster})
+		m.expiry.DeleteLabelValues(cluster)
+	}
+	m.clusters = clusters.Clone()
+}
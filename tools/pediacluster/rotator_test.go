@@ -0,0 +1,367 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+	"testing"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	kubefake "k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+	clienttesting "k8s.io/client-go/testing"
+	"k8s.io/client-go/tools/clientcmd"
+	"k8s.io/client-go/tools/record"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+func newToken(issuedAt, expiry time.Time) string {
+	encode := base64.RawURLEncoding.EncodeToString
+	payload := fmt.Sprintf(`{"iss":"kubernetes/serviceaccount","iat":%d,"exp":%d}`, issuedAt.Unix(), expiry.Unix())
+	return encode([]byte(`{"alg":"RS256"}`)) + "." + encode([]byte(payload)) + ".signature"
+}
+
+// inlineKubeconfig returns the test kubeconfig without files, as it is stored in a PediaCluster.
+func inlineKubeconfig(token string) []byte {
+	config := strings.Replace(kubeconfig, "tokenFile: token", "token: "+token, 1)
+	return []byte(strings.Replace(config, "certificate-authority: ca.crt", "insecure-skip-tls-verify: true", 1))
+}
+
+func newCertificate(t *testing.T, notBefore, notAfter time.Time) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "clusterpedia"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func TestCredentialValidity(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	tests := []struct {
+		name string
+		spec clusterv1alpha2.ClusterSpec
+		want *Validity
+	}{
+		{
+			name: "bound token",
+			spec: clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", TokenData: []byte(newToken(now, now.Add(time.Hour)))},
+			want: &Validity{NotBefore: now, NotAfter: now.Add(time.Hour)},
+		},
+		{
+			name: "opaque token",
+			spec: clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", TokenData: []byte("opaque")},
+		},
+		{
+			name: "earliest of token and certificate",
+			spec: clusterv1alpha2.ClusterSpec{
+				APIServer: "https://member:6443",
+				TokenData: []byte(newToken(now, now.Add(time.Hour))),
+				CertData:  newCertificate(t, now, now.Add(time.Minute)),
+			},
+			want: &Validity{NotBefore: now, NotAfter: now.Add(time.Minute)},
+		},
+		{
+			name: "kubeconfig",
+			spec: clusterv1alpha2.ClusterSpec{Kubeconfig: inlineKubeconfig(newToken(now, now.Add(time.Hour)))},
+			want: &Validity{NotBefore: now, NotAfter: now.Add(time.Hour)},
+		},
+	}
+	for _, tt := range tests {
+		got, err := CredentialValidity(tt.spec)
+		if err != nil {
+			t.Errorf("%s: unexpected error: %v", tt.name, err)
+			continue
+		}
+		if (got == nil) != (tt.want == nil) || got != nil && (!got.NotBefore.Equal(tt.want.NotBefore) || !got.NotAfter.Equal(tt.want.NotAfter)) {
+			t.Errorf("%s: expected validity %v, got %v", tt.name, tt.want, got)
+		}
+	}
+
+	if _, err := CredentialValidity(clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", CertData: []byte("invalid")}); err == nil {
+		t.Error("expected error of invalid certificate")
+	}
+}
+
+func TestRotator(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	expiring := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "expiring"},
+		Spec:       clusterv1alpha2.ClusterSpec{APIServer: "https://expiring:6443", TokenData: []byte(newToken(now.Add(-50*time.Minute), now.Add(10*time.Minute)))},
+	}
+	fresh := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "fresh"},
+		Spec:       clusterv1alpha2.ClusterSpec{APIServer: "https://fresh:6443", TokenData: []byte(newToken(now, now.Add(time.Hour)))},
+	}
+	kubeconfigCluster := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig"},
+		Spec:       clusterv1alpha2.ClusterSpec{Kubeconfig: inlineKubeconfig(newToken(now.Add(-time.Hour), now))},
+	}
+	legacy := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "legacy"},
+		Spec:       clusterv1alpha2.ClusterSpec{APIServer: "https://legacy:6443", TokenData: []byte("opaque")},
+	}
+
+	newToken := newToken(now, now.Add(24*time.Hour))
+	var requested []string
+	provider := CredentialProviderFunc(func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+		requested = append(requested, cluster.Name)
+		return &Credentials{TokenData: []byte(newToken)}, nil
+	})
+
+	clientset := fake.NewSimpleClientset(expiring, fresh, kubeconfigCluster, legacy)
+	clusters := clientset.ClusterV1alpha2().PediaClusters()
+	recorder := record.NewFakeRecorder(10)
+	metrics := NewRotationMetrics()
+	rotator, err := NewRotator(clusters, RotatorOptions{Provider: provider, Recorder: recorder, Metrics: metrics, Clock: testingclock.NewFakeClock(now)})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := rotator.RotateAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sort.Strings(requested)
+	if strings.Join(requested, ",") != "expiring,kubeconfig" {
+		t.Errorf("expected the credentials of expiring and kubeconfig are requested, got %v", requested)
+	}
+
+	cluster, err := clusters.Get(context.Background(), "expiring", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cluster.Spec.TokenData) != newToken {
+		t.Errorf("expected the token is rotated, got %q", cluster.Spec.TokenData)
+	}
+
+	cluster, err = clusters.Get(context.Background(), "kubeconfig", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := clientcmd.RESTConfigFromKubeConfig(cluster.Spec.Kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config.BearerToken != newToken || config.Host != "https://member:6443" {
+		t.Errorf("expected the token of the kubeconfig is rotated, got %s %q", config.Host, config.BearerToken)
+	}
+
+	for i := 0; i < 2; i++ {
+		if event := <-recorder.Events; !strings.HasPrefix(event, "Normal "+ReasonCredentialsRotated) {
+			t.Errorf("unexpected event: %s", event)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.rotations.WithLabelValues("expiring", "success")); got != 1 {
+		t.Errorf("expected 1 rotation of expiring, got %v", got)
+	}
+	if got := testutil.ToFloat64(metrics.expiry.WithLabelValues("expiring")); got != float64(now.Add(24*time.Hour).Unix()) {
+		t.Errorf("expected the expiry of the new token, got %v", got)
+	}
+	if got := testutil.CollectAndCount(metrics.expiry); got != 3 {
+		t.Errorf("expected the expiry of 3 clusters, got %d", got)
+	}
+
+	if err := clusters.Delete(context.Background(), "fresh", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	failing := CredentialProviderFunc(func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+		return nil, fmt.Errorf("member cluster is unreachable")
+	})
+	rotator, _ = NewRotator(clusters, RotatorOptions{Provider: failing, Recorder: recorder, Metrics: metrics, Clock: testingclock.NewFakeClock(now.Add(23 * time.Hour))})
+	if err := rotator.RotateAll(context.Background()); err == nil || !strings.Contains(err.Error(), "unreachable") {
+		t.Errorf("expected error of the provider, got %v", err)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning "+ReasonCredentialRotationFailed) {
+		t.Errorf("unexpected event: %s", event)
+	}
+	if got := testutil.CollectAndCount(metrics.expiry); got != 2 {
+		t.Errorf("expected the expiry of the deleted cluster is dropped, got %d series", got)
+	}
+}
+
+func TestRotatorReplacesCredentials(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	expiringCert := newCertificate(t, now.Add(-time.Hour), now)
+	certAndToken := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "cert-and-token"},
+		Spec: clusterv1alpha2.ClusterSpec{
+			APIServer: "https://member:6443",
+			TokenData: []byte(newToken(now, now.Add(time.Hour))),
+			CertData:  expiringCert,
+			KeyData:   []byte("key"),
+		},
+	}
+	config, err := clientcmd.Load(inlineKubeconfig(newToken(now, now.Add(time.Hour))))
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.AuthInfos["admin"].ClientCertificateData, config.AuthInfos["admin"].ClientKeyData = expiringCert, []byte("key")
+	certKubeconfig, err := clientcmd.Write(*config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kubeconfigCluster := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "kubeconfig"},
+		Spec:       clusterv1alpha2.ClusterSpec{Kubeconfig: certKubeconfig},
+	}
+
+	freshToken := newToken(now, now.Add(24*time.Hour))
+	provider := CredentialProviderFunc(func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+		return &Credentials{TokenData: []byte(freshToken)}, nil
+	})
+	clientset := fake.NewSimpleClientset(certAndToken, kubeconfigCluster)
+	clusters := clientset.ClusterV1alpha2().PediaClusters()
+	recorder := record.NewFakeRecorder(10)
+	rotator, _ := NewRotator(clusters, RotatorOptions{Provider: provider, Recorder: recorder, Clock: testingclock.NewFakeClock(now)})
+	if err := rotator.RotateAll(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	cluster, err := clusters.Get(context.Background(), "cert-and-token", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(cluster.Spec.TokenData) != freshToken || len(cluster.Spec.CertData) != 0 || len(cluster.Spec.KeyData) != 0 {
+		t.Errorf("expected the certificate is replaced by the token, got %+v", cluster.Spec)
+	}
+	cluster, err = clusters.Get(context.Background(), "kubeconfig", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	rotated, err := clientcmd.RESTConfigFromKubeConfig(cluster.Spec.Kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated.BearerToken != freshToken || len(rotated.CertData) != 0 || len(rotated.KeyData) != 0 {
+		t.Errorf("expected the certificate of the kubeconfig is replaced by the token, got %q %q", rotated.BearerToken, rotated.CertData)
+	}
+	for i := 0; i < 2; i++ {
+		if event := <-recorder.Events; !strings.HasPrefix(event, "Normal "+ReasonCredentialsRotated) {
+			t.Errorf("unexpected event: %s", event)
+		}
+	}
+
+	// the provider issues credentials expiring as soon as the current ones
+	expiring := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "expiring"},
+		Spec:       clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", TokenData: []byte(newToken(now.Add(-time.Hour), now))},
+	}
+	shortLived := CredentialProviderFunc(func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+		return &Credentials{TokenData: []byte(newToken(now.Add(-time.Hour), now.Add(time.Minute)))}, nil
+	})
+	metrics := NewRotationMetrics()
+	rotator, _ = NewRotator(fake.NewSimpleClientset(expiring).ClusterV1alpha2().PediaClusters(), RotatorOptions{
+		Provider: shortLived, Recorder: recorder, Metrics: metrics, Clock: testingclock.NewFakeClock(now),
+	})
+	if rotated, err := rotator.Rotate(context.Background(), expiring); rotated || err == nil || !strings.Contains(err.Error(), "still due for renewal") {
+		t.Errorf("expected the rotation to short-lived credentials fails, got %v %v", rotated, err)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning "+ReasonCredentialRotationFailed) {
+		t.Errorf("unexpected event: %s", event)
+	}
+	if got := testutil.ToFloat64(metrics.rotations.WithLabelValues("expiring", "failure")); got != 1 {
+		t.Errorf("expected 1 failed rotation, got %v", got)
+	}
+}
+
+func TestRotatorWithoutRecorder(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	expiring := &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "expiring"},
+		Spec:       clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", TokenData: []byte(newToken(now.Add(-time.Hour), now))},
+	}
+	issuedAt, expiry := now, now.Add(24*time.Hour)
+	provider := CredentialProviderFunc(func(ctx context.Context, cluster *clusterv1alpha2.PediaCluster) (*Credentials, error) {
+		return &Credentials{TokenData: []byte(newToken(issuedAt, expiry))}, nil
+	})
+	rotator, err := NewRotator(fake.NewSimpleClientset(expiring).ClusterV1alpha2().PediaClusters(), RotatorOptions{
+		Provider: provider, Clock: testingclock.NewFakeClock(now),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rotated, err := rotator.Rotate(context.Background(), expiring); !rotated || err != nil {
+		t.Errorf("expected the credentials are rotated, got %v %v", rotated, err)
+	}
+
+	issuedAt, expiry = now.Add(-time.Hour), now.Add(time.Minute)
+	if rotated, err := rotator.Rotate(context.Background(), expiring); rotated || err == nil {
+		t.Errorf("expected the rotation to short-lived credentials fails, got %v %v", rotated, err)
+	}
+}
+
+func TestTokenRequestProvider(t *testing.T) {
+	member := kubefake.NewSimpleClientset()
+	member.PrependReactor("create", "serviceaccounts", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		create := action.(clienttesting.CreateAction)
+		if create.GetSubresource() != "token" {
+			return false, nil, nil
+		}
+		request := create.GetObject().(*authenticationv1.TokenRequest)
+		if *request.Spec.ExpirationSeconds != 3600 || create.GetNamespace() != DefaultServiceAccountNamespace {
+			return true, nil, fmt.Errorf("unexpected token request %s: %+v", create.GetNamespace(), request.Spec)
+		}
+		request.Status.Token = "bound-token"
+		return true, request, nil
+	})
+
+	var host string
+	provider := &TokenRequestProvider{
+		Expiration: time.Hour,
+		NewClient: func(config *rest.Config) (kubernetes.Interface, error) {
+			host = config.Host
+			return member, nil
+		},
+	}
+	creds, err := provider.Credentials(context.Background(), &clusterv1alpha2.PediaCluster{
+		Spec: clusterv1alpha2.ClusterSpec{APIServer: "https://member:6443", TokenData: []byte("old-token")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(creds.TokenData) != "bound-token" || host != "https://member:6443" {
+		t.Errorf("unexpected credentials %q from %s", creds.TokenData, host)
+	}
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"fmt"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ResourceSyncCondition returns the sync condition of a resource of the
+// cluster. An empty version matches all the versions of the resource, the
+// condition of the worst status among them is returned, so the resource is
+// Syncing only if every version is.
+func ResourceSyncCondition(cluster *clusterv1alpha2.PediaCluster, gvr schema.GroupVersionResource) (*clusterv1alpha2.ClusterResourceSyncCondition, bool) {
+	var worst *clusterv1alpha2.ClusterResourceSyncCondition
+	for _, group := range cluster.Status.SyncResources {
+		if group.Group != gvr.Group {
+			continue
+		}
+		for _, resource := range group.Resources {
+			if resource.Name != gvr.Resource {
+				continue
+			}
+			for i, cond := range resource.SyncConditions {
+				if gvr.Version != "" && cond.Version == gvr.Version {
+					return &resource.SyncConditions[i], true
+				}
+				if gvr.Version == "" && (worst == nil || syncSeverity(cond.Status) > syncSeverity(worst.Status)) {
+					worst = &resource.SyncConditions[i]
+				}
+			}
+		}
+	}
+	return worst, worst != nil
+}
+
+// syncSeverity orders the sync statuses from Syncing to the failures.
+func syncSeverity(status string) int {
+	switch status {
+	case clusterv1alpha2.ResourceSyncStatusSyncing:
+		return 0
+	case clusterv1alpha2.ResourceSyncStatusPending:
+		return 1
+	case clusterv1alpha2.ResourceSyncStatusStop:
+		return 2
+	}
+	return 3
+}
+
+// IsResourceSynced returns true if the resource of the cluster is being synchronized.
+func IsResourceSynced(cluster *clusterv1alpha2.PediaCluster, gvr schema.GroupVersionResource) bool {
+	cond, ok := ResourceSyncCondition(cluster, gvr)
+	return ok && cond.Status == clusterv1alpha2.ResourceSyncStatusSyncing
+}
+
+// IsReady returns true if the Ready condition of the cluster is true.
+func IsReady(cluster *clusterv1alpha2.PediaCluster) bool {
+	return meta.IsStatusConditionTrue(cluster.Status.Conditions, clusterv1alpha2.ReadyCondition)
+}
+
+// describeCondition explains why the condition of the cluster is not true,
+// it returns an empty string if it is true.
+func describeCondition(cluster *clusterv1alpha2.PediaCluster, conditionType string) string {
+	cond := meta.FindStatusCondition(cluster.Status.Conditions, conditionType)
+	switch {
+	case cond == nil:
+		return fmt.Sprintf("condition %s is not reported", conditionType)
+	case cond.Status == metav1.ConditionTrue:
+		return ""
+	case cond.Message != "":
+		return fmt.Sprintf("condition %s is %s (%s: %s)", conditionType, cond.Status, cond.Reason, cond.Message)
+	}
+	return fmt.Sprintf("condition %s is %s (%s)", conditionType, cond.Status, cond.Reason)
+}
+
+// describeResource explains why the resource of the cluster is not synced,
+// it returns an empty string if it is synced.
+func describeResource(cluster *clusterv1alpha2.PediaCluster, gvr schema.GroupVersionResource) string {
+	cond, ok := ResourceSyncCondition(cluster, gvr)
+	switch {
+	case !ok:
+		return fmt.Sprintf("resource %s is not in the sync status", gvr)
+	case cond.Status == clusterv1alpha2.ResourceSyncStatusSyncing:
+		return ""
+	case cond.Message != "":
+		return fmt.Sprintf("resource %s is %s (%s: %s)", gvr, cond.Status, cond.Reason, cond.Message)
+	case cond.Reason != "":
+		return fmt.Sprintf("resource %s is %s (%s)", gvr, cond.Status, cond.Reason)
+	}
+	return fmt.Sprintf("resource %s is %s", gvr, cond.Status)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"testing"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestResourceSyncCondition(t *testing.T) {
+	cluster := newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing)
+	resource := &cluster.Status.SyncResources[0].Resources[0]
+	resource.SyncConditions = append(resource.SyncConditions,
+		clusterv1alpha2.ClusterResourceSyncCondition{Version: "v1beta1", Status: clusterv1alpha2.ResourceSyncStatusSyncing},
+		clusterv1alpha2.ClusterResourceSyncCondition{Version: "v1beta2", Status: clusterv1alpha2.ResourceSyncStatusError, Reason: "WatchError"},
+		clusterv1alpha2.ClusterResourceSyncCondition{Version: "v1beta3", Status: clusterv1alpha2.ResourceSyncStatusPending},
+	)
+
+	tests := []struct {
+		version string
+		want    string
+	}{
+		{version: "v1", want: clusterv1alpha2.ResourceSyncStatusSyncing},
+		{version: "v1beta3", want: clusterv1alpha2.ResourceSyncStatusPending},
+		{version: "", want: clusterv1alpha2.ResourceSyncStatusError},
+	}
+	for _, tt := range tests {
+		gvr := schema.GroupVersionResource{Group: "apps", Version: tt.version, Resource: "deployments"}
+		cond, ok := ResourceSyncCondition(cluster, gvr)
+		if !ok || cond.Status != tt.want {
+			t.Errorf("Unexpect condition of %s: %+v, expect status: %s", gvr, cond, tt.want)
+		}
+	}
+	if IsResourceSynced(cluster, schema.GroupVersionResource{Group: "apps", Resource: "deployments"}) {
+		t.Errorf("Expect the resource with a failed version is not synced")
+	}
+
+	resource.SyncConditions = resource.SyncConditions[:2]
+	if !IsResourceSynced(cluster, schema.GroupVersionResource{Group: "apps", Resource: "deployments"}) {
+		t.Errorf("Expect the resource syncing in every version is synced")
+	}
+	if _, ok := ResourceSyncCondition(cluster, schema.GroupVersionResource{Group: "apps", Version: "v2", Resource: "deployments"}); ok {
+		t.Errorf("Unexpect condition of a version not synchronized")
+	}
+}
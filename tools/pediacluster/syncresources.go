@@ -0,0 +1,199 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/sets"
+	clientretry "k8s.io/client-go/util/retry"
+)
+
+// Wildcard selects all resources of a group.
+const Wildcard = "*"
+
+// AddSyncResources adds the resources to the sync resources of the PediaCluster,
+// see MergeSyncResources. The update is retried on conflict.
+func (c *Client) AddSyncResources(ctx context.Context, name string, resources ...clusterv1alpha2.ClusterGroupResources) (*clusterv1alpha2.PediaCluster, error) {
+	return c.updateSpec(ctx, name, func(spec *clusterv1alpha2.ClusterSpec) error {
+		spec.SyncResources = MergeSyncResources(spec.SyncResources, resources...)
+		return nil
+	})
+}
+
+// RemoveSyncResources removes the resources from the sync resources of the
+// PediaCluster, see RemoveFromSyncResources. The update is retried on conflict.
+func (c *Client) RemoveSyncResources(ctx context.Context, name string, resources ...clusterv1alpha2.ClusterGroupResources) (*clusterv1alpha2.PediaCluster, error) {
+	return c.updateSpec(ctx, name, func(spec *clusterv1alpha2.ClusterSpec) error {
+		syncResources, err := RemoveFromSyncResources(spec.SyncResources, resources...)
+		if err != nil {
+			return err
+		}
+		spec.SyncResources = syncResources
+		return nil
+	})
+}
+
+// SetSyncAllCustomResources sets whether all the custom resources of the member
+// cluster are synchronized. The update is retried on conflict.
+func (c *Client) SetSyncAllCustomResources(ctx context.Context, name string, syncAll bool) (*clusterv1alpha2.PediaCluster, error) {
+	return c.updateSpec(ctx, name, func(spec *clusterv1alpha2.ClusterSpec) error {
+		spec.SyncAllCustomResources = syncAll
+		return nil
+	})
+}
+
+// updateSpec applies mutate to the latest PediaCluster, the PediaCluster is
+// not updated if mutate does not change it.
+func (c *Client) updateSpec(ctx context.Context, name string, mutate func(spec *clusterv1alpha2.ClusterSpec) error) (*clusterv1alpha2.PediaCluster, error) {
+	var cluster *clusterv1alpha2.PediaCluster
+	err := clientretry.RetryOnConflict(clientretry.DefaultRetry, func() error {
+		current, err := c.clusters.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+
+		updated := current.DeepCopy()
+		if err := mutate(&updated.Spec); err != nil {
+			return err
+		}
+		if updated.Spec.SyncResources == nil {
+			updated.Spec.SyncResources = []clusterv1alpha2.ClusterGroupResources{}
+		}
+		if reflect.DeepEqual(current.Spec, updated.Spec) {
+			cluster = current
+			return nil
+		}
+
+		cluster, err = c.clusters.Update(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cluster, nil
+}
+
+// MergeSyncResources returns the union of the sync resources. The resources of
+// a group are merged into the entry of the group with the same versions, the
+// versions and the resources are deduplicated, and a Wildcard absorbs all the
+// other resources of the entry.
+func MergeSyncResources(existing []clusterv1alpha2.ClusterGroupResources, resources ...clusterv1alpha2.ClusterGroupResources) []clusterv1alpha2.ClusterGroupResources {
+	merged := make([]clusterv1alpha2.ClusterGroupResources, 0, len(existing)+len(resources))
+	for _, groupResources := range append(append([]clusterv1alpha2.ClusterGroupResources{}, existing...), resources...) {
+		groupResources = normalize(groupResources)
+		if i := indexOf(merged, groupResources); i >= 0 {
+			merged[i].Resources = mergeResources(merged[i].Resources, groupResources.Resources)
+			continue
+		}
+		merged = append(merged, groupResources)
+	}
+	return merged
+}
+
+// RemoveFromSyncResources removes the resources from the sync resources. Without
+// versions the resources are removed from every entry of the group, and a
+// Wildcard removes the whole entry. The entries left without resources are removed.
+// A single resource can not be removed from an entry with a Wildcard.
+func RemoveFromSyncResources(existing []clusterv1alpha2.ClusterGroupResources, resources ...clusterv1alpha2.ClusterGroupResources) ([]clusterv1alpha2.ClusterGroupResources, error) {
+	result := make([]clusterv1alpha2.ClusterGroupResources, 0, len(existing))
+	for _, groupResources := range existing {
+		groupResources = normalize(groupResources)
+		for _, remove := range resources {
+			remove = normalize(remove)
+			if remove.Group != groupResources.Group || (len(remove.Versions) != 0 && !equalVersions(remove.Versions, groupResources.Versions)) {
+				continue
+			}
+
+			removed := sets.New[string](remove.Resources...)
+			if removed.Has(Wildcard) {
+				groupResources.Resources = nil
+				break
+			}
+			if sets.New[string](groupResources.Resources...).Has(Wildcard) {
+				return nil, fmt.Errorf("can not remove %v from all resources of group %q, remove %q instead",
+					remove.Resources, groupResources.Group, Wildcard)
+			}
+
+			var left []string
+			for _, resource := range groupResources.Resources {
+				if !removed.Has(resource) {
+					left = append(left, resource)
+				}
+			}
+			groupResources.Resources = left
+		}
+
+		if len(groupResources.Resources) != 0 {
+			result = append(result, groupResources)
+		}
+	}
+	return result, nil
+}
+
+func normalize(groupResources clusterv1alpha2.ClusterGroupResources) clusterv1alpha2.ClusterGroupResources {
+	return clusterv1alpha2.ClusterGroupResources{
+		Group:     groupResources.Group,
+		Versions:  dedup(groupResources.Versions),
+		Resources: mergeResources(nil, groupResources.Resources),
+	}
+}
+
+func indexOf(resources []clusterv1alpha2.ClusterGroupResources, groupResources clusterv1alpha2.ClusterGroupResources) int {
+	for i, r := range resources {
+		if r.Group == groupResources.Group && equalVersions(r.Versions, groupResources.Versions) {
+			return i
+		}
+	}
+	return -1
+}
+
+func mergeResources(a, b []string) []string {
+	resources := dedup(append(append([]string{}, a...), b...))
+	for _, resource := range resources {
+		if resource == Wildcard {
+			return []string{Wildcard}
+		}
+	}
+	return resources
+}
+
+// dedup returns the values without duplicates in their first order, nil is kept as nil.
+func dedup(values []string) []string {
+	if len(values) == 0 {
+		return values
+	}
+
+	seen := sets.New[string]()
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if !seen.Has(value) {
+			seen.Insert(value)
+			unique = append(unique, value)
+		}
+	}
+	return unique
+}
+
+// equalVersions compares the versions regardless of their order.
+func equalVersions(a, b []string) bool {
+	return sets.New[string](a...).Equal(sets.New[string](b...))
+}
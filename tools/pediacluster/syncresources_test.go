@@ -0,0 +1,119 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clienttesting "k8s.io/client-go/testing"
+
+	pediafake "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+type groupResources = clusterv1alpha2.ClusterGroupResources
+
+func TestMergeSyncResources(t *testing.T) {
+	existing := []groupResources{
+		{Group: "apps", Resources: []string{"deployments"}},
+		{Group: "", Versions: []string{"v1"}, Resources: []string{"pods"}},
+	}
+
+	merged := MergeSyncResources(existing,
+		groupResources{Group: "apps", Resources: []string{"statefulsets", "deployments"}},
+		groupResources{Group: "", Versions: []string{"v1", "v1"}, Resources: []string{"configmaps"}},
+		groupResources{Group: "batch", Resources: []string{"jobs", "*"}},
+	)
+	expect := []groupResources{
+		{Group: "apps", Resources: []string{"deployments", "statefulsets"}},
+		{Group: "", Versions: []string{"v1"}, Resources: []string{"pods", "configmaps"}},
+		{Group: "batch", Resources: []string{"*"}},
+	}
+	if !reflect.DeepEqual(merged, expect) {
+		t.Errorf("Unexpect merged resources: %+v, expect: %+v", merged, expect)
+	}
+	if !reflect.DeepEqual(MergeSyncResources(merged, expect...), expect) {
+		t.Errorf("Merge is not idempotent")
+	}
+}
+
+func TestRemoveFromSyncResources(t *testing.T) {
+	existing := []groupResources{
+		{Group: "apps", Versions: []string{"v1"}, Resources: []string{"deployments", "statefulsets"}},
+		{Group: "apps", Versions: []string{"v1beta1"}, Resources: []string{"deployments"}},
+		{Group: "batch", Resources: []string{"*"}},
+	}
+
+	result, err := RemoveFromSyncResources(existing,
+		groupResources{Group: "apps", Resources: []string{"deployments"}},
+		groupResources{Group: "batch", Resources: []string{"*"}},
+	)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	expect := []groupResources{{Group: "apps", Versions: []string{"v1"}, Resources: []string{"statefulsets"}}}
+	if !reflect.DeepEqual(result, expect) {
+		t.Errorf("Unexpect resources: %+v, expect: %+v", result, expect)
+	}
+
+	if _, err := RemoveFromSyncResources(existing, groupResources{Group: "batch", Resources: []string{"jobs"}}); err == nil {
+		t.Errorf("Expect error of removing a resource from a wildcard")
+	}
+}
+
+func TestAddSyncResources(t *testing.T) {
+	cs := pediafake.NewSimpleClientset(&clusterv1alpha2.PediaCluster{ObjectMeta: metav1.ObjectMeta{Name: "member"}})
+	// the first update fails with a conflict
+	conflicts := 1
+	cs.PrependReactor("update", "pediaclusters", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if conflicts == 0 {
+			return false, nil, nil
+		}
+		conflicts--
+		return true, nil, apierrors.NewConflict(clusterv1alpha2.Resource("pediaclusters"), "member", nil)
+	})
+	c := New(cs.ClusterV1alpha2().PediaClusters())
+
+	deployments := groupResources{Group: "apps", Resources: []string{"deployments"}}
+	cluster, err := c.AddSyncResources(context.TODO(), "member", deployments)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if !reflect.DeepEqual(cluster.Spec.SyncResources, []groupResources{deployments}) {
+		t.Errorf("Unexpect resources: %+v", cluster.Spec.SyncResources)
+	}
+
+	if _, err := c.AddSyncResources(context.TODO(), "member", deployments); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	// the conflicted update and its retry, registering again updates nothing
+	if updates := countActions(cs, "update"); updates != 2 {
+		t.Errorf("Unexpect updates: %d, expect: %d", updates, 2)
+	}
+
+	if cluster, err = c.SetSyncAllCustomResources(context.TODO(), "member", true); err != nil || !cluster.Spec.SyncAllCustomResources {
+		t.Errorf("Unexpect result: %v, %v", cluster, err)
+	}
+	if cluster, err = c.RemoveSyncResources(context.TODO(), "member", deployments); err != nil || len(cluster.Spec.SyncResources) != 0 {
+		t.Errorf("Unexpect result: %v, %v", cluster, err)
+	}
+}
@@ -0,0 +1,160 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+	watchtools "k8s.io/client-go/tools/watch"
+)
+
+// WaitError is returned when the wait for a PediaCluster times out or is
+// canceled, it explains what is still pending and wraps the error of the
+// context.
+type WaitError struct {
+	Cluster string
+
+	// For is what was waited for, such as "ready".
+	For string
+
+	// Pending are the conditions or the resources still pending when the
+	// wait ended.
+	Pending []string
+
+	Err error
+}
+
+func (e *WaitError) Error() string {
+	reason := "timed out"
+	if errors.Is(e.Err, context.Canceled) {
+		reason = "canceled"
+	}
+	msg := fmt.Sprintf("%s waiting for PediaCluster %s to be %s", reason, e.Cluster, e.For)
+	if len(e.Pending) != 0 {
+		msg += ": " + strings.Join(e.Pending, "; ")
+	}
+	return msg
+}
+
+func (e *WaitError) Unwrap() error {
+	return e.Err
+}
+
+// WaitForClusterReady waits until the Ready condition of the PediaCluster is
+// true, a timeout of zero waits until ctx is done. It fails immediately if the
+// config of the PediaCluster is invalid.
+func (c *Client) WaitForClusterReady(ctx context.Context, name string, timeout time.Duration) (*clusterv1alpha2.PediaCluster, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	return c.wait(ctx, name, "ready", func(cluster *clusterv1alpha2.PediaCluster) ([]string, error) {
+		if cond := meta.FindStatusCondition(cluster.Status.Conditions, clusterv1alpha2.ValidatedCondition); cond != nil &&
+			cond.Status == metav1.ConditionFalse && cond.Reason == clusterv1alpha2.InvalidConfigReason {
+			return nil, fmt.Errorf("PediaCluster %s is invalid: %s", name, cond.Message)
+		}
+		if IsReady(cluster) {
+			return nil, nil
+		}
+
+		var pending []string
+		for _, conditionType := range []string{
+			clusterv1alpha2.ValidatedCondition,
+			clusterv1alpha2.SynchroRunningCondition,
+			clusterv1alpha2.ClusterHealthyCondition,
+			clusterv1alpha2.ReadyCondition,
+		} {
+			if msg := describeCondition(cluster, conditionType); msg != "" {
+				pending = append(pending, msg)
+			}
+		}
+		return pending, nil
+	})
+}
+
+// WaitForResourcesSynced waits until all the resources are synchronized from the
+// member cluster, a resource without version waits for all of its versions. The
+// wait ends when ctx is done.
+func (c *Client) WaitForResourcesSynced(ctx context.Context, name string, gvrs ...schema.GroupVersionResource) (*clusterv1alpha2.PediaCluster, error) {
+	return c.wait(ctx, name, "synced", func(cluster *clusterv1alpha2.PediaCluster) ([]string, error) {
+		var pending []string
+		for _, gvr := range gvrs {
+			if msg := describeResource(cluster, gvr); msg != "" {
+				pending = append(pending, msg)
+			}
+		}
+		return pending, nil
+	})
+}
+
+// wait watches the PediaCluster until check reports nothing pending.
+func (c *Client) wait(ctx context.Context, name, waitFor string, check func(*clusterv1alpha2.PediaCluster) ([]string, error)) (*clusterv1alpha2.PediaCluster, error) {
+	selector := fields.OneTermEqualSelector("metadata.name", name).String()
+	lw := &cache.ListWatch{
+		ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+			options.FieldSelector = selector
+			return c.clusters.List(ctx, options)
+		},
+		WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+			options.FieldSelector = selector
+			return c.clusters.Watch(ctx, options)
+		},
+	}
+
+	var last *clusterv1alpha2.PediaCluster
+	pending := []string{"PediaCluster is not found"}
+	_, err := watchtools.UntilWithSync(ctx, lw, &clusterv1alpha2.PediaCluster{}, nil, func(event watch.Event) (bool, error) {
+		cluster, ok := event.Object.(*clusterv1alpha2.PediaCluster)
+		if !ok {
+			return false, nil
+		}
+		if event.Type == watch.Deleted {
+			last, pending = nil, []string{"PediaCluster is deleted"}
+			return false, nil
+		}
+
+		var err error
+		last = cluster
+		pending, err = check(cluster)
+		return len(pending) == 0 && err == nil, err
+	})
+	if err == nil {
+		return last, nil
+	}
+	if wait.Interrupted(err) || ctx.Err() != nil {
+		if ctx.Err() != nil {
+			err = ctx.Err()
+		}
+		return last, &WaitError{Cluster: name, For: waitFor, Pending: pending, Err: err}
+	}
+	return last, err
+}
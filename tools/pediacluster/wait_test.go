@@ -0,0 +1,167 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	clienttesting "k8s.io/client-go/testing"
+
+	pediafake "github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+)
+
+var pediaClustersResource = clusterv1alpha2.SchemeGroupVersion.WithResource("pediaclusters")
+
+// watchStarted returns a channel closed once the PediaClusters of cs are
+// watched, the tracker only sends the changes to the registered watchers.
+func watchStarted(cs *pediafake.Clientset) <-chan struct{} {
+	started := make(chan struct{})
+	var once sync.Once
+	cs.PrependWatchReactor("pediaclusters", func(action clienttesting.Action) (bool, watch.Interface, error) {
+		w, err := cs.Tracker().Watch(action.GetResource(), action.GetNamespace())
+		once.Do(func() { close(started) })
+		return true, w, err
+	})
+	return started
+}
+
+// countActions returns the number of the requests of verb to PediaClusters.
+func countActions(cs *pediafake.Clientset, verb string) int {
+	var count int
+	for _, action := range cs.Actions() {
+		if action.GetVerb() == verb && action.GetResource() == pediaClustersResource {
+			count++
+		}
+	}
+	return count
+}
+
+func newCluster(ready bool, syncStatus string) *clusterv1alpha2.PediaCluster {
+	readyStatus := metav1.ConditionFalse
+	if ready {
+		readyStatus = metav1.ConditionTrue
+	}
+	return &clusterv1alpha2.PediaCluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "member", ResourceVersion: "1"},
+		Status: clusterv1alpha2.ClusterStatus{
+			Conditions: []metav1.Condition{
+				{Type: clusterv1alpha2.ValidatedCondition, Status: metav1.ConditionTrue, Reason: clusterv1alpha2.ValidatedReason},
+				{Type: clusterv1alpha2.ClusterHealthyCondition, Status: readyStatus, Reason: clusterv1alpha2.ClusterNotReachableReason, Message: "connection refused"},
+				{Type: clusterv1alpha2.ReadyCondition, Status: readyStatus, Reason: clusterv1alpha2.NotReadyReason},
+			},
+			SyncResources: []clusterv1alpha2.ClusterGroupResourcesStatus{{
+				Group: "apps",
+				Resources: []clusterv1alpha2.ClusterResourceStatus{{
+					Name: "deployments",
+					Kind: "Deployment",
+					SyncConditions: []clusterv1alpha2.ClusterResourceSyncCondition{
+						{Version: "v1", Status: syncStatus, Reason: "WaitInit"},
+					},
+				}},
+			}},
+		},
+	}
+}
+
+func TestWaitForClusterReady(t *testing.T) {
+	cs := pediafake.NewSimpleClientset(newCluster(false, clusterv1alpha2.ResourceSyncStatusPending))
+	c := New(cs.ClusterV1alpha2().PediaClusters())
+
+	_, err := c.WaitForClusterReady(context.TODO(), "member", 100*time.Millisecond)
+	var waitErr *WaitError
+	if !errors.As(err, &waitErr) {
+		t.Fatalf("Expect wait error, got: %v", err)
+	}
+	if msg := err.Error(); !strings.Contains(msg, "condition ClusterHealthy is False (NotReachable: connection refused)") ||
+		!strings.Contains(msg, "condition SynchroRunning is not reported") {
+		t.Errorf("Unexpect error message: %s", msg)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expect deadline exceeded, got: %v", waitErr.Err)
+	}
+	if msg := err.Error(); !strings.HasPrefix(msg, "timed out waiting for PediaCluster member to be ready") {
+		t.Errorf("Unexpect error message: %s", msg)
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	cancel()
+	_, err = c.WaitForClusterReady(ctx, "member", 0)
+	if !errors.Is(err, context.Canceled) || !strings.HasPrefix(err.Error(), "canceled waiting for PediaCluster member to be ready") {
+		t.Errorf("Unexpect error of canceled wait: %v", err)
+	}
+
+	cs = pediafake.NewSimpleClientset(newCluster(false, clusterv1alpha2.ResourceSyncStatusPending))
+	started := watchStarted(cs)
+	go func() {
+		<-started
+		ready := newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing)
+		ready.ResourceVersion = "2"
+		if err := cs.Tracker().Update(pediaClustersResource, ready, ""); err != nil {
+			t.Errorf("Unexpect error: %v", err)
+		}
+	}()
+	cluster, err := New(cs.ClusterV1alpha2().PediaClusters()).WaitForClusterReady(context.TODO(), "member", 5*time.Second)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if !IsReady(cluster) {
+		t.Errorf("Expect ready cluster")
+	}
+}
+
+func TestWaitForResourcesSynced(t *testing.T) {
+	cs := pediafake.NewSimpleClientset(newCluster(true, clusterv1alpha2.ResourceSyncStatusPending))
+	c := New(cs.ClusterV1alpha2().PediaClusters())
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), 100*time.Millisecond)
+	defer cancel()
+	_, err := c.WaitForResourcesSynced(ctx, "member", deployments, pods)
+	var waitErr *WaitError
+	if !errors.As(err, &waitErr) || len(waitErr.Pending) != 2 {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if waitErr.Pending[0] != "resource apps/v1, Resource=deployments is Pending (WaitInit)" ||
+		waitErr.Pending[1] != "resource /v1, Resource=pods is not in the sync status" {
+		t.Errorf("Unexpect pending: %q", waitErr.Pending)
+	}
+
+	cs = pediafake.NewSimpleClientset(newCluster(true, clusterv1alpha2.ResourceSyncStatusPending))
+	started := watchStarted(cs)
+	go func() {
+		<-started
+		synced := newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing)
+		synced.ResourceVersion = "2"
+		if err := cs.Tracker().Update(pediaClustersResource, synced, ""); err != nil {
+			t.Errorf("Unexpect error: %v", err)
+		}
+	}()
+	if _, err := New(cs.ClusterV1alpha2().PediaClusters()).WaitForResourcesSynced(context.TODO(), "member", schema.GroupVersionResource{Group: "apps", Resource: "deployments"}); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/utils/clock"
+
+	clusterinformers "github.com/clusterpedia-io/client-go/pkg/generated/informers/externalversions/cluster/v1alpha2"
+)
+
+const DefaultDebounce = 10 * time.Second
+
+// LifecycleEventType is the type of a change in the lifecycle of a PediaCluster.
+type LifecycleEventType string
+
+const (
+	// ClusterJoined is sent when a PediaCluster is added.
+	ClusterJoined LifecycleEventType = "Joined"
+
+	// ClusterReady is sent when a PediaCluster becomes ready for the first time.
+	ClusterReady LifecycleEventType = "Ready"
+
+	// ClusterDegraded is sent when a ready PediaCluster is no longer ready.
+	ClusterDegraded LifecycleEventType = "Degraded"
+
+	// ClusterRecovered is sent when a degraded PediaCluster is ready again.
+	ClusterRecovered LifecycleEventType = "Recovered"
+
+	// ClusterRemoved is sent when a PediaCluster is deleted.
+	ClusterRemoved LifecycleEventType = "Removed"
+)
+
+// LifecycleEvent is a change in the lifecycle of a PediaCluster, Cluster is the
+// last observed object and Health its summary.
+type LifecycleEvent struct {
+	Type    LifecycleEventType
+	Cluster *clusterv1alpha2.PediaCluster
+	Health  ClusterHealth
+}
+
+// LifecycleHandler is called with the lifecycle events in the order they happen.
+type LifecycleHandler func(event LifecycleEvent)
+
+type WatcherOptions struct {
+	// Debounce is how long a cluster must stay ready or not ready before
+	// Ready, Degraded or Recovered is sent, defaults to DefaultDebounce.
+	// A negative Debounce sends the events immediately.
+	Debounce time.Duration
+
+	Clock clock.WithDelayedExecution
+}
+
+// ClusterWatcher translates the events of a PediaClusterInformer into
+// lifecycle events. The ready changes are debounced, so a cluster flapping
+// faster than the debounce does not send any event.
+type ClusterWatcher struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+	debounce     time.Duration
+	clock        clock.WithDelayedExecution
+
+	lock     sync.Mutex
+	handlers []LifecycleHandler
+	clusters map[string]*clusterState
+
+	// queue is not bounded, dropping an event would break the order of the
+	// lifecycle, so it grows until Run delivers the events.
+	queue  []queuedEvent
+	notify chan struct{}
+}
+
+// queuedEvent is an event waiting for Run along with the handlers registered
+// when it was sent.
+type queuedEvent struct {
+	event    LifecycleEvent
+	handlers []LifecycleHandler
+}
+
+// clusterState is the ready state of a cluster reported to the handlers, and the
+// pending change waiting for the debounce.
+type clusterState struct {
+	cluster   *clusterv1alpha2.PediaCluster
+	ready     bool
+	everReady bool
+
+	timer      clock.Timer
+	generation uint64
+}
+
+// NewClusterWatcher registers the watcher to the informer, the events are
+// delivered to the handlers once Run is called. The clusters existing when the
+// informer starts are sent as Joined, and as Ready if they are ready.
+//
+// The events are queued in memory until they are delivered, so Run should be
+// started along with the informer and the handlers should not block.
+func NewClusterWatcher(informer clusterinformers.PediaClusterInformer, opts WatcherOptions) (*ClusterWatcher, error) {
+	w := &ClusterWatcher{
+		informer: informer.Informer(),
+		debounce: opts.Debounce,
+		clock:    opts.Clock,
+		clusters: make(map[string]*clusterState),
+		notify:   make(chan struct{}, 1),
+	}
+	if w.debounce == 0 {
+		w.debounce = DefaultDebounce
+	}
+	if w.clock == nil {
+		w.clock = clock.RealClock{}
+	}
+
+	registration, err := w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    w.add,
+		UpdateFunc: func(_, obj interface{}) { w.update(obj) },
+		DeleteFunc: w.delete,
+	})
+	if err != nil {
+		return nil, err
+	}
+	w.registration = registration
+	return w, nil
+}
+
+// AddHandler registers handler, it receives the events sent after it is added.
+// The events already queued but not delivered yet are not sent to handler.
+func (w *ClusterWatcher) AddHandler(handler LifecycleHandler) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	// the queued events share the handlers, so they are never appended in place
+	w.handlers = append(w.handlers[:len(w.handlers):len(w.handlers)], handler)
+}
+
+// HasSynced returns true once the clusters of the initial list of the informer
+// are observed by the watcher.
+func (w *ClusterWatcher) HasSynced() bool {
+	return w.registration.HasSynced()
+}
+
+// Run delivers the events to the handlers until ctx is done, the handlers are
+// called one event at a time. The watcher is removed from the informer when Run
+// returns, it cannot be run again.
+func (w *ClusterWatcher) Run(ctx context.Context) {
+	defer w.stopTimers()
+	defer func() { _ = w.informer.RemoveEventHandler(w.registration) }()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.notify:
+		}
+
+		for {
+			events := w.pop()
+			if len(events) == 0 {
+				break
+			}
+			for _, queued := range events {
+				for _, handler := range queued.handlers {
+					handler(queued.event)
+				}
+			}
+		}
+	}
+}
+
+func (w *ClusterWatcher) pop() []queuedEvent {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	events := w.queue
+	w.queue = nil
+	return events
+}
+
+func (w *ClusterWatcher) stopTimers() {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	for _, state := range w.clusters {
+		state.cancel()
+	}
+}
+
+func (w *ClusterWatcher) add(obj interface{}) {
+	w.update(obj)
+}
+
+func (w *ClusterWatcher) update(obj interface{}) {
+	cluster, ok := obj.(*clusterv1alpha2.PediaCluster)
+	if !ok {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.observe(cluster)
+}
+
+func (w *ClusterWatcher) delete(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	cluster, ok := obj.(*clusterv1alpha2.PediaCluster)
+	if !ok {
+		return
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if state, ok := w.clusters[cluster.Name]; ok {
+		state.cancel()
+		delete(w.clusters, cluster.Name)
+	}
+	w.enqueue(ClusterRemoved, cluster)
+}
+
+// observe records the latest object of the cluster, and starts the debounce
+// of a ready change or cancels it if the cluster is back to the reported state.
+func (w *ClusterWatcher) observe(cluster *clusterv1alpha2.PediaCluster) {
+	ready := IsReady(cluster)
+	state, ok := w.clusters[cluster.Name]
+	if !ok {
+		state = &clusterState{cluster: cluster}
+		w.clusters[cluster.Name] = state
+		w.enqueue(ClusterJoined, cluster)
+
+		// a cluster already ready when it is observed first has nothing to settle
+		if ready {
+			w.transition(state, true)
+		}
+		return
+	}
+	state.cluster = cluster
+
+	if ready == state.ready {
+		state.cancel()
+		return
+	}
+	if state.timer != nil {
+		// the debounce of this change is already running
+		return
+	}
+	if w.debounce < 0 {
+		w.transition(state, ready)
+		return
+	}
+
+	generation := state.generation
+	state.timer = w.clock.AfterFunc(w.debounce, func() {
+		w.lock.Lock()
+		defer w.lock.Unlock()
+
+		if w.clusters[cluster.Name] != state || state.generation != generation {
+			return
+		}
+		state.timer = nil
+		w.transition(state, ready)
+	})
+}
+
+func (w *ClusterWatcher) transition(state *clusterState, ready bool) {
+	state.ready = ready
+	switch {
+	case !ready:
+		w.enqueue(ClusterDegraded, state.cluster)
+	case state.everReady:
+		w.enqueue(ClusterRecovered, state.cluster)
+	default:
+		state.everReady = true
+		w.enqueue(ClusterReady, state.cluster)
+	}
+}
+
+func (w *ClusterWatcher) enqueue(eventType LifecycleEventType, cluster *clusterv1alpha2.PediaCluster) {
+	w.queue = append(w.queue, queuedEvent{
+		event:    LifecycleEvent{Type: eventType, Cluster: cluster, Health: Summarize(cluster)},
+		handlers: w.handlers,
+	})
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+// cancel stops the pending debounce, the timer may have fired already
+// so the generation invalidates its callback.
+func (state *clusterState) cancel() {
+	if state.timer == nil {
+		return
+	}
+	state.timer.Stop()
+	state.timer = nil
+	state.generation++
+}
@@ -0,0 +1,220 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package pediacluster
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	clusterv1alpha2 "github.com/clusterpedia-io/api/cluster/v1alpha2"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/tools/cache"
+	testingclock "k8s.io/utils/clock/testing"
+
+	"github.com/clusterpedia-io/client-go/pkg/generated/clientset/versioned/fake"
+	"github.com/clusterpedia-io/client-go/pkg/generated/informers/externalversions"
+)
+
+func popTypes(w *ClusterWatcher) []LifecycleEventType {
+	events := w.pop()
+	types := make([]LifecycleEventType, 0, len(events))
+	for _, queued := range events {
+		types = append(types, queued.event.Type)
+	}
+	return types
+}
+
+func TestClusterWatcherDebounce(t *testing.T) {
+	fakeClock := testingclock.NewFakeClock(time.Now())
+	factory := externalversions.NewSharedInformerFactory(fake.NewSimpleClientset(), 0)
+	w, err := NewClusterWatcher(factory.Cluster().V1alpha2().PediaClusters(), WatcherOptions{Debounce: 10 * time.Second, Clock: fakeClock})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		name    string
+		ready   *bool
+		deleted bool
+		step    time.Duration
+		want    []LifecycleEventType
+	}{
+		{name: "joined not ready", ready: boolPtr(false), want: []LifecycleEventType{ClusterJoined}},
+		{name: "ready is debounced", ready: boolPtr(true), step: 5 * time.Second, want: []LifecycleEventType{}},
+		{name: "ready after debounce", step: 5 * time.Second, want: []LifecycleEventType{ClusterReady}},
+		{name: "flapping", ready: boolPtr(false), step: 5 * time.Second, want: []LifecycleEventType{}},
+		{name: "back before debounce", ready: boolPtr(true), step: 10 * time.Second, want: []LifecycleEventType{}},
+		{name: "not ready", ready: boolPtr(false), step: 5 * time.Second, want: []LifecycleEventType{}},
+		{name: "still not ready", ready: boolPtr(false), step: 5 * time.Second, want: []LifecycleEventType{ClusterDegraded}},
+		{name: "recovered", ready: boolPtr(true), step: 10 * time.Second, want: []LifecycleEventType{ClusterRecovered}},
+		{name: "pending change is dropped on removal", ready: boolPtr(false), deleted: true, step: 10 * time.Second, want: []LifecycleEventType{ClusterRemoved}},
+		{name: "joined ready", ready: boolPtr(true), want: []LifecycleEventType{ClusterJoined, ClusterReady}},
+	}
+	for _, step := range steps {
+		if step.ready != nil {
+			w.update(newCluster(*step.ready, clusterv1alpha2.ResourceSyncStatusSyncing))
+		}
+		if step.deleted {
+			w.delete(cache.DeletedFinalStateUnknown{Key: "member", Obj: newCluster(false, "")})
+		}
+		fakeClock.Step(step.step)
+
+		if got := popTypes(w); !reflect.DeepEqual(got, step.want) {
+			t.Errorf("%s: expected events %v, got %v", step.name, step.want, got)
+		}
+	}
+}
+
+func TestClusterWatcher(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newCluster(false, clusterv1alpha2.ResourceSyncStatusPending))
+	factory := externalversions.NewSharedInformerFactory(clientset, 0)
+	w, err := NewClusterWatcher(factory.Cluster().V1alpha2().PediaClusters(), WatcherOptions{Debounce: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	events := make(chan LifecycleEvent, 10)
+	w.AddHandler(func(event LifecycleEvent) { events <- event })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+	go w.Run(ctx)
+	if !cache.WaitForCacheSync(ctx.Done(), w.HasSynced) {
+		t.Fatal("watcher is not synced")
+	}
+
+	expect := func(want LifecycleEventType) LifecycleEvent {
+		t.Helper()
+		select {
+		case event := <-events:
+			if event.Type != want {
+				t.Fatalf("expected %s event, got %s", want, event.Type)
+			}
+			return event
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s event", want)
+		}
+		return LifecycleEvent{}
+	}
+
+	event := expect(ClusterJoined)
+	if event.Cluster.Name != "member" || event.Health.PendingVersions != 1 {
+		t.Errorf("unexpected joined event: %+v", event)
+	}
+
+	clusters := clientset.ClusterV1alpha2().PediaClusters()
+	if _, err := clusters.Update(ctx, newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if event := expect(ClusterReady); !event.Health.Ready {
+		t.Errorf("expected ready health, got %+v", event.Health)
+	}
+
+	if err := clusters.Delete(ctx, "member", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	expect(ClusterRemoved)
+}
+
+func TestClusterWatcherAddHandler(t *testing.T) {
+	clientset := fake.NewSimpleClientset(newCluster(false, clusterv1alpha2.ResourceSyncStatusPending))
+	factory := externalversions.NewSharedInformerFactory(clientset, 0)
+	w, err := NewClusterWatcher(factory.Cluster().V1alpha2().PediaClusters(), WatcherOptions{Debounce: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	first, second := make(chan LifecycleEvent, 10), make(chan LifecycleEvent, 10)
+	w.AddHandler(func(event LifecycleEvent) { first <- event })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), w.HasSynced) {
+		t.Fatal("watcher is not synced")
+	}
+
+	// Joined is queued before the second handler is added and delivered after
+	w.AddHandler(func(event LifecycleEvent) { second <- event })
+	go w.Run(ctx)
+
+	if _, err := clientset.ClusterV1alpha2().PediaClusters().Update(ctx, newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	for handler, want := range map[chan LifecycleEvent][]LifecycleEventType{
+		first:  {ClusterJoined, ClusterReady},
+		second: {ClusterReady},
+	} {
+		for _, eventType := range want {
+			select {
+			case event := <-handler:
+				if event.Type != eventType {
+					t.Fatalf("expected %s event, got %s", eventType, event.Type)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timed out waiting for %s event", eventType)
+			}
+		}
+	}
+}
+
+func TestClusterWatcherStop(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	factory := externalversions.NewSharedInformerFactory(clientset, 0)
+	informer := factory.Cluster().V1alpha2().PediaClusters()
+	w, err := NewClusterWatcher(informer, WatcherOptions{Debounce: -1})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	factory.Start(ctx.Done())
+
+	runCtx, stop := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		w.Run(runCtx)
+		close(done)
+	}()
+	stop()
+	<-done
+
+	if _, err := clientset.ClusterV1alpha2().PediaClusters().Create(ctx, newCluster(true, clusterv1alpha2.ResourceSyncStatusSyncing), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		_, err := informer.Lister().Get("member")
+		return err == nil, nil
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if len(w.queue) != 0 || len(w.clusters) != 0 {
+		t.Errorf("unexpected events after the watcher is stopped: %+v", w.queue)
+	}
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
@@ -0,0 +1,339 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"k8s.io/client-go/util/flowcontrol"
+	"k8s.io/utils/clock"
+
+	"github.com/clusterpedia-io/client-go/tools/requestinfo"
+)
+
+// State is the state of the circuit breaker of a cluster.
+type State string
+
+const (
+	// StateClosed lets the requests through.
+	StateClosed State = "Closed"
+
+	// StateOpen fails the requests without sending them.
+	StateOpen State = "Open"
+
+	// StateHalfOpen lets a single probe request through after OpenTimeout,
+	// the breaker is closed if the probe succeeds and opened again otherwise.
+	StateHalfOpen State = "HalfOpen"
+)
+
+// Limits are the rate limit and the circuit breaker settings of a cluster.
+type Limits struct {
+	// QPS and Burst configure the token bucket of the cluster,
+	// a negative QPS disables rate limiting.
+	QPS   float32
+	Burst int
+
+	// FailureThreshold is the number of consecutive failures opening the
+	// breaker, a negative threshold disables the breaker.
+	FailureThreshold int
+
+	// OpenTimeout is how long the breaker stays open before a probe is let through.
+	OpenTimeout time.Duration
+}
+
+// DefaultLimits are the limits of a cluster without an override.
+func DefaultLimits() Limits {
+	return Limits{
+		QPS:              100,
+		Burst:            200,
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+	}
+}
+
+// complete fills the zero fields of l from defaults.
+func (l Limits) complete(defaults Limits) Limits {
+	if l.QPS == 0 {
+		l.QPS = defaults.QPS
+	}
+	if l.Burst == 0 {
+		l.Burst = defaults.Burst
+	}
+	if l.FailureThreshold == 0 {
+		l.FailureThreshold = defaults.FailureThreshold
+	}
+	if l.OpenTimeout == 0 {
+		l.OpenTimeout = defaults.OpenTimeout
+	}
+	return l
+}
+
+type Options struct {
+	// Default are the limits of every cluster, the zero fields are taken
+	// from DefaultLimits.
+	Default Limits
+
+	// Overrides are the limits of specific clusters, the zero fields are
+	// taken from Default.
+	Overrides map[string]Limits
+
+	// OnStateChange is called when the breaker of a cluster changes its state.
+	OnStateChange func(cluster string, from, to State)
+
+	// Clock defaults to the real clock.
+	Clock clock.Clock
+}
+
+// CircuitOpenError is returned for the requests to a cluster whose breaker is open.
+type CircuitOpenError struct {
+	Cluster string
+
+	// RetryAfter is the time left before the breaker lets a probe through.
+	RetryAfter time.Duration
+}
+
+func (e *CircuitOpenError) Error() string {
+	return fmt.Sprintf("circuit breaker of cluster %s is open, retry after %s", e.Cluster, e.RetryAfter)
+}
+
+// IsCircuitOpen returns true if err is caused by an open circuit breaker.
+func IsCircuitOpen(err error) bool {
+	var circuitOpen *CircuitOpenError
+	return errors.As(err, &circuitOpen)
+}
+
+// Limiter holds a token bucket and a circuit breaker per cluster, so that a
+// slow member cluster does not starve the requests to the others. Only the
+// requests with a cluster in the path, which are sent by the clients of
+// client.ClusterClient and client.ProxyClusterClient, are limited.
+type Limiter struct {
+	defaults      Limits
+	overrides     map[string]Limits
+	onStateChange func(cluster string, from, to State)
+	clock         clock.Clock
+
+	lock     sync.Mutex
+	breakers map[string]*breaker
+}
+
+func New(opts Options) *Limiter {
+	l := &Limiter{
+		defaults:      opts.Default.complete(DefaultLimits()),
+		overrides:     opts.Overrides,
+		onStateChange: opts.OnStateChange,
+		clock:         opts.Clock,
+		breakers:      make(map[string]*breaker),
+	}
+	if l.clock == nil {
+		l.clock = clock.RealClock{}
+	}
+	return l
+}
+
+// State returns the state of the breaker of the cluster.
+func (l *Limiter) State(cluster string) State {
+	l.lock.Lock()
+	b, ok := l.breakers[cluster]
+	l.lock.Unlock()
+	if !ok {
+		return StateClosed
+	}
+	return b.currentState()
+}
+
+// States returns the state of the breakers of the clusters that have been called.
+func (l *Limiter) States() map[string]State {
+	l.lock.Lock()
+	breakers := make(map[string]*breaker, len(l.breakers))
+	for cluster, b := range l.breakers {
+		breakers[cluster] = b
+	}
+	l.lock.Unlock()
+
+	states := make(map[string]State, len(breakers))
+	for cluster, b := range breakers {
+		states[cluster] = b.currentState()
+	}
+	return states
+}
+
+func (l *Limiter) breakerFor(cluster string) *breaker {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	if b, ok := l.breakers[cluster]; ok {
+		return b
+	}
+
+	limits := l.defaults
+	if override, ok := l.overrides[cluster]; ok {
+		limits = override.complete(l.defaults)
+	}
+	b := &breaker{cluster: cluster, limits: limits, limiter: l, state: StateClosed}
+	if limits.QPS > 0 {
+		b.tokens = flowcontrol.NewTokenBucketRateLimiterWithClock(limits.QPS, limits.Burst, l.clock)
+	}
+	l.breakers[cluster] = b
+	return b
+}
+
+// WrapConfig returns a copy of cfg whose transport applies the limits of l to
+// the requests of every cluster.
+func WrapConfig(cfg *rest.Config, l *Limiter) *rest.Config {
+	config := rest.CopyConfig(cfg)
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return NewRoundTripper(l, rt)
+	})
+	return config
+}
+
+// NewRoundTripper wraps rt, applying the limits of l.
+func NewRoundTripper(l *Limiter, rt http.RoundTripper) http.RoundTripper {
+	return &roundTripper{limiter: l, delegate: rt}
+}
+
+type roundTripper struct {
+	limiter  *Limiter
+	delegate http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	cluster := requestinfo.New(req).Cluster
+	if cluster == "" {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	b := rt.limiter.breakerFor(cluster)
+	if err := b.allow(); err != nil {
+		return nil, err
+	}
+	if b.tokens != nil {
+		if err := b.tokens.Wait(req.Context()); err != nil {
+			b.release()
+			return nil, err
+		}
+	}
+
+	resp, err := rt.delegate.RoundTrip(req)
+	switch {
+	case err != nil && req.Context().Err() == context.Canceled:
+		// canceled by the caller, the cluster is not to blame
+		b.release()
+	case err != nil || resp.StatusCode >= http.StatusInternalServerError:
+		b.failure()
+	default:
+		b.success()
+	}
+	return resp, err
+}
+
+type breaker struct {
+	cluster string
+	limits  Limits
+	limiter *Limiter
+	tokens  flowcontrol.RateLimiter
+
+	lock     sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+func (b *breaker) currentState() State {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.state
+}
+
+// allow returns a CircuitOpenError if the request must not be sent.
+func (b *breaker) allow() error {
+	if b.limits.FailureThreshold < 0 {
+		return nil
+	}
+
+	b.lock.Lock()
+	from := b.state
+	switch b.state {
+	case StateOpen:
+		elapsed := b.limiter.clock.Since(b.openedAt)
+		if elapsed < b.limits.OpenTimeout {
+			b.lock.Unlock()
+			return &CircuitOpenError{Cluster: b.cluster, RetryAfter: b.limits.OpenTimeout - elapsed}
+		}
+		b.state, b.probing = StateHalfOpen, true
+	case StateHalfOpen:
+		if b.probing {
+			b.lock.Unlock()
+			return &CircuitOpenError{Cluster: b.cluster}
+		}
+		b.probing = true
+	}
+	to := b.state
+	b.lock.Unlock()
+
+	b.notify(from, to)
+	return nil
+}
+
+// release gives back the probe of a request whose result is unknown.
+func (b *breaker) release() {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.probing = false
+}
+
+func (b *breaker) success() {
+	b.lock.Lock()
+	from := b.state
+	b.state, b.failures, b.probing = StateClosed, 0, false
+	b.lock.Unlock()
+
+	b.notify(from, StateClosed)
+}
+
+func (b *breaker) failure() {
+	if b.limits.FailureThreshold < 0 {
+		return
+	}
+
+	b.lock.Lock()
+	from := b.state
+	b.failures++
+	b.probing = false
+	if b.state == StateHalfOpen || (b.state == StateClosed && b.failures >= b.limits.FailureThreshold) {
+		b.state, b.openedAt = StateOpen, b.limiter.clock.Now()
+	}
+	to := b.state
+	b.lock.Unlock()
+
+	b.notify(from, to)
+}
+
+// notify is called without the lock held, so that OnStateChange can read the states.
+func (b *breaker) notify(from, to State) {
+	if from != to && b.limiter.onStateChange != nil {
+		b.limiter.onStateChange(b.cluster, from, to)
+	}
+}
@@ -0,0 +1,114 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package ratelimit
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+func TestLimiter(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.Contains(r.URL.Path, "/clusters/cluster-slow/") && !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	var transitions []string
+	fakeClock := clocktesting.NewFakeClock(time.Now())
+	limiter := New(Options{
+		Default:   Limits{QPS: -1, FailureThreshold: 3, OpenTimeout: time.Minute},
+		Overrides: map[string]Limits{"cluster-slow": {FailureThreshold: 2}},
+		OnStateChange: func(cluster string, from, to State) {
+			transitions = append(transitions, cluster+" "+string(from)+"->"+string(to))
+		},
+		Clock: fakeClock,
+	})
+	c := &http.Client{Transport: NewRoundTripper(limiter, http.DefaultTransport)}
+	get := func(cluster string) error {
+		resp, err := c.Get(server.URL + "/apis/clusterpedia.io/v1beta1/resources/clusters/" + cluster + "/proxy/api/v1/pods")
+		if err == nil {
+			resp.Body.Close()
+		}
+		return err
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := get("cluster-slow"); err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+	}
+	if state := limiter.State("cluster-slow"); state != StateOpen {
+		t.Fatalf("Unexpect state: %s, expect: %s", state, StateOpen)
+	}
+	if err := get("cluster-slow"); !IsCircuitOpen(err) {
+		t.Errorf("Expect circuit open error, got: %v", err)
+	}
+	if err := get("cluster-ok"); err != nil {
+		t.Errorf("Unexpect error of other cluster: %v", err)
+	}
+
+	// the probe fails and opens the breaker again
+	fakeClock.Step(time.Minute)
+	if err := get("cluster-slow"); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if state := limiter.State("cluster-slow"); state != StateOpen {
+		t.Fatalf("Unexpect state: %s, expect: %s", state, StateOpen)
+	}
+
+	healthy.Store(true)
+	fakeClock.Step(time.Minute)
+	if err := get("cluster-slow"); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	states := limiter.States()
+	if states["cluster-slow"] != StateClosed || states["cluster-ok"] != StateClosed {
+		t.Errorf("Unexpect states: %v", states)
+	}
+	expect := []string{
+		"cluster-slow Closed->Open",
+		"cluster-slow Open->HalfOpen",
+		"cluster-slow HalfOpen->Open",
+		"cluster-slow Open->HalfOpen",
+		"cluster-slow HalfOpen->Closed",
+	}
+	if strings.Join(transitions, ",") != strings.Join(expect, ",") {
+		t.Errorf("Unexpect transitions: %v, expect: %v", transitions, expect)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	limiter := New(Options{Overrides: map[string]Limits{"cluster-01": {QPS: 1}}})
+	if limits := limiter.breakerFor("cluster-01").limits; limits.QPS != 1 || limits.Burst != DefaultLimits().Burst {
+		t.Errorf("Unexpect limits of override: %+v", limits)
+	}
+	if limits := limiter.breakerFor("cluster-02").limits; limits != DefaultLimits() {
+		t.Errorf("Unexpect default limits: %+v", limits)
+	}
+}
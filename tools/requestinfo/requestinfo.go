@@ -0,0 +1,172 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestinfo
+
+import (
+	"net/http"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+// RequestInfo describes a request sent to clusterpedia apiserver.
+type RequestInfo struct {
+	// Verb is the kubernetes verb of the request, such as get, list or watch.
+	Verb string
+
+	// Cluster is the cluster in the path of the request, it is empty for
+	// the requests searching all clusters.
+	Cluster string
+
+	// Proxy is true for the requests sent through the proxy path to the
+	// member cluster.
+	Proxy bool
+
+	// Search is true for the requests served by the clusterpedia resources path.
+	Search bool
+
+	Resource    schema.GroupVersionResource
+	Subresource string
+	Namespace   string
+	Name        string
+
+	// Clusters and Namespaces are the clusters and namespaces selected by
+	// the search labels of the request.
+	Clusters   []string
+	Namespaces []string
+
+	OrderBy   bool
+	FuzzyName bool
+}
+
+// New returns the RequestInfo of req.
+func New(req *http.Request) *RequestInfo {
+	info := &RequestInfo{}
+
+	path := req.URL.Path
+	if i := strings.Index(path, constants.ClusterPediaAPIPath); i >= 0 {
+		info.Search = true
+		path = path[i+len(constants.ClusterPediaAPIPath):]
+		if strings.HasPrefix(path, constants.ClusterAPIPath) {
+			path = strings.TrimPrefix(path, constants.ClusterAPIPath)
+			info.Cluster, path, _ = strings.Cut(path, "/")
+			path = "/" + path
+
+			if strings.HasPrefix(path, "/proxy/") || path == "/proxy" {
+				info.Search, info.Proxy = false, true
+				path = strings.TrimPrefix(path, "/proxy")
+			}
+		}
+	}
+	info.parsePath(path)
+	info.Verb = verbOf(req, info.Name)
+
+	if info.Search {
+		info.parseSearch(req)
+	}
+	return info
+}
+
+// parsePath parses the kubernetes API path, such as
+// /apis/apps/v1/namespaces/default/deployments/foo/status.
+func (info *RequestInfo) parsePath(path string) {
+	parts := strings.Split(strings.Trim(path, "/"), "/")
+	switch {
+	case len(parts) >= 2 && parts[0] == "api":
+		info.Resource.Version = parts[1]
+		parts = parts[2:]
+	case len(parts) >= 3 && parts[0] == "apis":
+		info.Resource.Group, info.Resource.Version = parts[1], parts[2]
+		parts = parts[3:]
+	default:
+		return
+	}
+
+	if len(parts) >= 2 && parts[0] == "namespaces" {
+		info.Namespace = parts[1]
+		// the subresources of the namespace itself stay in the path
+		if len(parts) >= 3 && parts[2] != "status" && parts[2] != "finalize" {
+			parts = parts[2:]
+		}
+	}
+
+	if len(parts) >= 1 {
+		info.Resource.Resource = parts[0]
+	}
+	if len(parts) >= 2 {
+		info.Name = parts[1]
+	}
+	if len(parts) >= 3 {
+		info.Subresource = strings.Join(parts[2:], "/")
+	}
+}
+
+func (info *RequestInfo) parseSearch(req *http.Request) {
+	query := req.URL.Query()
+	if info.Cluster != "" {
+		info.Clusters = []string{info.Cluster}
+	}
+	if info.Namespace != "" {
+		info.Namespaces = []string{info.Namespace}
+	}
+
+	selector, err := labels.Parse(query.Get("labelSelector"))
+	if err != nil {
+		return
+	}
+	requirements, _ := selector.Requirements()
+	for _, requirement := range requirements {
+		switch requirement.Key() {
+		case constants.SearchLabelClusters:
+			info.Clusters = requirement.Values().List()
+		case constants.SearchLabelNamespaces:
+			info.Namespaces = requirement.Values().List()
+		case constants.SearchLabelOrderBy:
+			info.OrderBy = true
+		case constants.SearchLabelFuzzyName:
+			info.FuzzyName = true
+		}
+	}
+}
+
+func verbOf(req *http.Request, name string) string {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead:
+		if req.URL.Query().Get("watch") == "true" {
+			return "watch"
+		}
+		if name == "" {
+			return "list"
+		}
+		return "get"
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut:
+		return "update"
+	case http.MethodPatch:
+		return "patch"
+	case http.MethodDelete:
+		if name == "" {
+			return "deletecollection"
+		}
+		return "delete"
+	}
+	return strings.ToLower(req.Method)
+}
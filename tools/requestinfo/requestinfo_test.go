@@ -0,0 +1,120 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package requestinfo
+
+import (
+	"net/http"
+	"net/url"
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		url    string
+		expect RequestInfo
+	}{
+		{
+			name:   "search all clusters",
+			method: http.MethodGet,
+			url:    "https://host/apis/clusterpedia.io/v1beta1/resources/apis/apps/v1/deployments?" + url.Values{"labelSelector": {"search.clusterpedia.io/clusters in (cluster-01,cluster-02),search.clusterpedia.io/orderby in (name)"}}.Encode(),
+			expect: RequestInfo{
+				Verb:     "list",
+				Search:   true,
+				Resource: schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+				Clusters: []string{"cluster-01", "cluster-02"},
+				OrderBy:  true,
+			},
+		},
+		{
+			name:   "search a cluster behind a base path",
+			method: http.MethodGet,
+			url:    "https://host/base/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01/api/v1/namespaces/default/pods/pod-a",
+			expect: RequestInfo{
+				Verb:       "get",
+				Cluster:    "cluster-01",
+				Search:     true,
+				Resource:   schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+				Namespace:  "default",
+				Name:       "pod-a",
+				Clusters:   []string{"cluster-01"},
+				Namespaces: []string{"default"},
+			},
+		},
+		{
+			name:   "fuzzy name search",
+			method: http.MethodGet,
+			url:    "https://host/apis/clusterpedia.io/v1beta1/resources/api/v1/pods?" + url.Values{"labelSelector": {"internalstorage.clusterpedia.io/fuzzy-name in (nginx)"}}.Encode(),
+			expect: RequestInfo{
+				Verb:      "list",
+				Search:    true,
+				Resource:  schema.GroupVersionResource{Version: "v1", Resource: "pods"},
+				FuzzyName: true,
+			},
+		},
+		{
+			name:   "proxy subresource",
+			method: http.MethodPatch,
+			url:    "https://host/apis/clusterpedia.io/v1beta1/resources/clusters/cluster-01/proxy/apis/apps/v1/namespaces/default/deployments/foo/status",
+			expect: RequestInfo{
+				Verb:        "patch",
+				Cluster:     "cluster-01",
+				Proxy:       true,
+				Resource:    schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+				Subresource: "status",
+				Namespace:   "default",
+				Name:        "foo",
+			},
+		},
+		{
+			name:   "watch pediaclusters",
+			method: http.MethodGet,
+			url:    "https://host/apis/cluster.clusterpedia.io/v1alpha2/pediaclusters?watch=true",
+			expect: RequestInfo{
+				Verb:     "watch",
+				Resource: schema.GroupVersionResource{Group: "cluster.clusterpedia.io", Version: "v1alpha2", Resource: "pediaclusters"},
+			},
+		},
+		{
+			name:   "namespace",
+			method: http.MethodDelete,
+			url:    "https://host/api/v1/namespaces/default",
+			expect: RequestInfo{
+				Verb:      "delete",
+				Resource:  schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+				Namespace: "default",
+				Name:      "default",
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req, err := http.NewRequest(test.method, test.url, nil)
+			if err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if info := New(req); !reflect.DeepEqual(*info, test.expect) {
+				t.Errorf("Unexpect request info: %+v, expect: %+v", *info, test.expect)
+			}
+		})
+	}
+}
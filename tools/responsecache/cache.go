@@ -0,0 +1,357 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package responsecache
+
+import (
+	"bytes"
+	"container/list"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+	"k8s.io/utils/clock"
+
+	"github.com/clusterpedia-io/client-go/tools/requestinfo"
+)
+
+const (
+	DefaultTTL              = 5 * time.Second
+	DefaultMaxEntries       = 1000
+	DefaultMaxBytes   int64 = 64 << 20
+)
+
+type Options struct {
+	// TTL is how long a response is served from the cache, defaults to DefaultTTL.
+	TTL time.Duration
+
+	// MaxEntries and MaxBytes bound the cache, the least recently used responses
+	// are evicted first. They default to DefaultMaxEntries and DefaultMaxBytes.
+	MaxEntries int
+	MaxBytes   int64
+
+	// Clock defaults to the real clock.
+	Clock clock.PassiveClock
+}
+
+// Cache holds the responses of the get and list requests sent to clusterpedia,
+// and coalesces the identical requests in flight into a single request.
+//
+// The responses are keyed by the canonical form of the request: the path with
+// the cluster and the resource, the query with the search labels ordered
+// deterministically, the pagination, the accepted content types, the
+// credentials of the request and the client certificate of the config.
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	maxBytes   int64
+	clock      clock.PassiveClock
+
+	group singleflight.Group
+
+	lock    sync.Mutex
+	lru     *list.List
+	entries map[string]*list.Element
+	size    int64
+}
+
+type entry struct {
+	key     string
+	expires time.Time
+
+	statusCode int
+	header     http.Header
+	body       []byte
+}
+
+func New(opts Options) *Cache {
+	c := &Cache{
+		ttl:        opts.TTL,
+		maxEntries: opts.MaxEntries,
+		maxBytes:   opts.MaxBytes,
+		clock:      opts.Clock,
+		lru:        list.New(),
+		entries:    make(map[string]*list.Element),
+	}
+	if c.ttl <= 0 {
+		c.ttl = DefaultTTL
+	}
+	if c.maxEntries <= 0 {
+		c.maxEntries = DefaultMaxEntries
+	}
+	if c.maxBytes <= 0 {
+		c.maxBytes = DefaultMaxBytes
+	}
+	if c.clock == nil {
+		c.clock = clock.RealClock{}
+	}
+	return c
+}
+
+// Len returns the number of the cached responses.
+func (c *Cache) Len() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.lru.Len()
+}
+
+// Purge removes all the cached responses.
+func (c *Cache) Purge() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.lru.Init()
+	c.entries = make(map[string]*list.Element)
+	c.size = 0
+}
+
+func (c *Cache) get(key string) (*entry, bool) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	e := elem.Value.(*entry)
+	if !c.clock.Now().Before(e.expires) {
+		c.remove(elem)
+		return nil, false
+	}
+	c.lru.MoveToFront(elem)
+	return e, true
+}
+
+func (c *Cache) add(e *entry) {
+	size := int64(len(e.body))
+	if size > c.maxBytes {
+		return
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if elem, ok := c.entries[e.key]; ok {
+		c.remove(elem)
+	}
+	c.entries[e.key] = c.lru.PushFront(e)
+	c.size += size
+
+	for c.lru.Len() > c.maxEntries || c.size > c.maxBytes {
+		c.remove(c.lru.Back())
+	}
+}
+
+// remove must be called with the lock held.
+func (c *Cache) remove(elem *list.Element) {
+	e := c.lru.Remove(elem).(*entry)
+	delete(c.entries, e.key)
+	c.size -= int64(len(e.body))
+}
+
+type bypassContextKey struct{}
+
+// WithoutCache returns a copy of ctx whose requests are neither served from
+// nor stored in the cache.
+func WithoutCache(ctx context.Context) context.Context {
+	return context.WithValue(ctx, bypassContextKey{}, true)
+}
+
+// WrapConfig returns a copy of cfg whose transport serves the get and list
+// requests from c. Every client of this module built from the returned config,
+// such as client.GetClient, dynamic.NewForConfig and customclient.NewForConfig,
+// shares the cache. The responses are kept apart by the client certificate of
+// cfg, so the configs of different users can share c.
+func WrapConfig(cfg *rest.Config, c *Cache) *rest.Config {
+	config := rest.CopyConfig(cfg)
+	identity := tlsIdentityOf(config)
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{cache: c, delegate: rt, tlsIdentity: identity}
+	})
+	return config
+}
+
+// NewRoundTripper wraps rt, serving the get and list requests from c. The client
+// certificate of rt is unknown, so the responses are only kept apart by the
+// credentials in the request headers, use WrapConfig for the clients
+// authenticating with client certificates.
+func NewRoundTripper(c *Cache, rt http.RoundTripper) http.RoundTripper {
+	return &roundTripper{cache: c, delegate: rt}
+}
+
+type roundTripper struct {
+	cache       *Cache
+	delegate    http.RoundTripper
+	tlsIdentity string
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if bypass, _ := req.Context().Value(bypassContextKey{}).(bool); bypass || !cacheable(req) {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	key := keyOf(req, rt.tlsIdentity)
+	if e, ok := rt.cache.get(key); ok {
+		return e.response(req), nil
+	}
+
+	result := rt.cache.group.DoChan(key, func() (interface{}, error) {
+		return rt.fetch(req, key)
+	})
+	select {
+	case <-req.Context().Done():
+		return nil, req.Context().Err()
+	case r := <-result:
+		if r.Err != nil {
+			// the request of another caller was canceled, send our own
+			if r.Shared && isContextError(r.Err) && req.Context().Err() == nil {
+				return rt.delegate.RoundTrip(req)
+			}
+			return nil, r.Err
+		}
+		return r.Val.(*entry).response(req), nil
+	}
+}
+
+// fetch sends the request and caches the response if it succeeded.
+func (rt *roundTripper) fetch(req *http.Request, key string) (*entry, error) {
+	resp, err := rt.delegate.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	e := &entry{
+		key:        key,
+		expires:    rt.cache.clock.Now().Add(rt.cache.ttl),
+		statusCode: resp.StatusCode,
+		header:     resp.Header.Clone(),
+		body:       body,
+	}
+	if resp.StatusCode == http.StatusOK {
+		rt.cache.add(e)
+	}
+	return e, nil
+}
+
+func (e *entry) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        http.StatusText(e.statusCode),
+		StatusCode:    e.statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(e.body)),
+		ContentLength: int64(len(e.body)),
+		Request:       req,
+	}
+}
+
+func cacheable(req *http.Request) bool {
+	if req.Method != http.MethodGet || req.Header.Get("Cache-Control") == "no-cache" {
+		return false
+	}
+	verb := requestinfo.New(req).Verb
+	return verb == "get" || verb == "list"
+}
+
+func isContextError(err error) bool {
+	return errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)
+}
+
+// Key returns the canonical form of the request the response is cached by,
+// the search labels are ordered deterministically whatever the order they
+// were built in.
+func Key(req *http.Request) string {
+	return keyOf(req, "")
+}
+
+func keyOf(req *http.Request, tlsIdentity string) string {
+	query := req.URL.Query()
+	for _, param := range []string{"labelSelector", "fieldSelector"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		if selector, err := labels.Parse(value); err == nil {
+			query.Set(param, selector.String())
+		}
+	}
+
+	var b strings.Builder
+	b.WriteString(req.URL.Host)
+	b.WriteString(strings.TrimSuffix(req.URL.Path, "/"))
+	b.WriteString("?")
+	b.WriteString(query.Encode())
+	b.WriteString("\naccept=")
+	b.WriteString(req.Header.Get("Accept"))
+
+	// the responses of different users must never be shared
+	if identity := identityOf(req.Header); identity != "" {
+		sum := sha256.Sum256([]byte(identity))
+		b.WriteString("\nidentity=")
+		b.WriteString(hex.EncodeToString(sum[:]))
+	}
+	if tlsIdentity != "" {
+		b.WriteString("\ntls=")
+		b.WriteString(tlsIdentity)
+	}
+	return b.String()
+}
+
+// tlsIdentityOf returns the digest of the client certificate of the config,
+// or "" if the config has none.
+func tlsIdentityOf(cfg *rest.Config) string {
+	if len(cfg.CertData) == 0 && cfg.CertFile == "" && cfg.KeyFile == "" {
+		return ""
+	}
+
+	h := sha256.New()
+	for _, data := range [][]byte{cfg.CertData, []byte(cfg.CertFile), []byte(cfg.KeyFile)} {
+		// the length prefix keeps the fields apart
+		_, _ = fmt.Fprintf(h, "%d:", len(data))
+		_, _ = h.Write(data)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func identityOf(header http.Header) string {
+	values := url.Values{}
+	for key, value := range header {
+		if key == "Authorization" || strings.HasPrefix(key, "Impersonate-") {
+			values[key] = value
+		}
+	}
+	return values.Encode()
+}
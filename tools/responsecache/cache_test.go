@@ -0,0 +1,198 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package responsecache
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/client-go/rest"
+	clocktesting "k8s.io/utils/clock/testing"
+)
+
+const searchPath = "/apis/clusterpedia.io/v1beta1/resources/apis/apps/v1/deployments"
+
+func TestKey(t *testing.T) {
+	newRequest := func(selector string) *http.Request {
+		req, _ := http.NewRequest(http.MethodGet, "https://host"+searchPath+"?"+url.Values{"labelSelector": {selector}, "limit": {"10"}}.Encode(), nil)
+		return req
+	}
+
+	a := newRequest("search.clusterpedia.io/namespaces=default,search.clusterpedia.io/clusters in (b,a)")
+	b := newRequest("search.clusterpedia.io/clusters in (a,b),search.clusterpedia.io/namespaces=default")
+	if Key(a) != Key(b) {
+		t.Errorf("Unexpect different keys of the same search:\n%s\n%s", Key(a), Key(b))
+	}
+
+	b.Header.Set("Authorization", "Bearer other")
+	if Key(a) == Key(b) {
+		t.Errorf("Expect different keys of different users")
+	}
+}
+
+func TestRoundTripper(t *testing.T) {
+	var requests atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-release
+		if r.URL.Query().Get("limit") == "0" {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write([]byte(r.URL.RawQuery))
+	}))
+	defer server.Close()
+
+	fakeClock := clocktesting.NewFakePassiveClock(time.Now())
+	cache := New(Options{TTL: time.Second, MaxEntries: 2, Clock: fakeClock})
+	c := &http.Client{Transport: NewRoundTripper(cache, http.DefaultTransport)}
+	get := func(ctx context.Context, limit string) (int, string) {
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+searchPath+"?limit="+limit, nil)
+		resp, err := c.Do(req)
+		if err != nil {
+			t.Errorf("Unexpect error: %v", err)
+			return 0, ""
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	// the concurrent identical searches are coalesced
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, body := get(context.TODO(), "1"); body != "limit=1" {
+				t.Errorf("Unexpect body: %q", body)
+			}
+		}()
+	}
+	for requests.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+	wg.Wait()
+	if got := requests.Load(); got != 1 {
+		t.Fatalf("Unexpect requests: %d, expect: %d", got, 1)
+	}
+
+	get(context.TODO(), "1")
+	get(WithoutCache(context.TODO()), "1")
+	if got := requests.Load(); got != 2 {
+		t.Errorf("Unexpect requests: %d, expect: %d", got, 2)
+	}
+
+	// the failed responses are not cached
+	for i := 0; i < 2; i++ {
+		if code, _ := get(context.TODO(), "0"); code != http.StatusInternalServerError {
+			t.Errorf("Unexpect code: %d", code)
+		}
+	}
+	if got := requests.Load(); got != 4 {
+		t.Errorf("Unexpect requests: %d, expect: %d", got, 4)
+	}
+
+	get(context.TODO(), "2")
+	get(context.TODO(), "3")
+	if cache.Len() != 2 {
+		t.Errorf("Unexpect entries: %d, expect: %d", cache.Len(), 2)
+	}
+
+	fakeClock.SetTime(fakeClock.Now().Add(time.Second))
+	get(context.TODO(), "3")
+	if got := requests.Load(); got != 7 {
+		t.Errorf("Unexpect requests after expiration: %d, expect: %d", got, 7)
+	}
+}
+
+func newClientCertificate(t *testing.T, user string) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: user},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+func TestWrapConfigClientCertificates(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(r.TLS.PeerCertificates[0].Subject.CommonName))
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	defer server.Close()
+
+	cache := New(Options{})
+	get := func(user string) string {
+		certData, keyData := newClientCertificate(t, user)
+		config := WrapConfig(&rest.Config{Host: server.URL, TLSClientConfig: rest.TLSClientConfig{Insecure: true, CertData: certData, KeyData: keyData}}, cache)
+		c, err := rest.HTTPClientFor(config)
+		if err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+		resp, err := c.Get(server.URL + searchPath)
+		if err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for _, user := range []string{"alice", "bob"} {
+		if got := get(user); got != user {
+			t.Errorf("Unexpect response of %s: %q", user, got)
+		}
+	}
+	if got := requests.Load(); got != 2 || cache.Len() != 2 {
+		t.Errorf("Unexpect requests: %d and entries: %d, expect: 2 and 2", got, cache.Len())
+	}
+}
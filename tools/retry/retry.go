@@ -0,0 +1,204 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry
+
+import (
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+)
+
+// Policy describes how requests that fail with a transient clusterpedia
+// error are retried.
+type Policy struct {
+	// MaxRetries is the number of retries after the first attempt.
+	MaxRetries int
+
+	// InitialBackoff is the wait before the first retry, it is doubled
+	// for every following retry up to MaxBackoff.
+	InitialBackoff time.Duration
+	MaxBackoff     time.Duration
+
+	// Jitter adds up to Jitter*backoff of random wait to every retry.
+	Jitter float64
+
+	// MaxRetryAfter is the longest Retry-After the policy will honour,
+	// a response asking for a longer wait is returned to the caller.
+	MaxRetryAfter time.Duration
+
+	// StatusCodes are the response codes considered transient.
+	StatusCodes []int
+}
+
+// DefaultPolicy retries 429 and 503 responses, which clusterpedia apiserver
+// returns when its storage layer is under load.
+func DefaultPolicy() Policy {
+	return Policy{
+		MaxRetries:     5,
+		InitialBackoff: 200 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		Jitter:         0.2,
+		MaxRetryAfter:  30 * time.Second,
+		StatusCodes:    []int{http.StatusTooManyRequests, http.StatusServiceUnavailable},
+	}
+}
+
+// WrapConfig returns a copy of cfg whose transport retries transient errors
+// according to policy. Every client of this module built from the returned
+// config, such as client.GetClient, dynamic.NewForConfig, customclient.NewForConfig
+// and clusterpediaclient.NewForConfig, shares the retry behavior.
+//
+// The clients of client-go already retry the responses carrying a Retry-After
+// in seconds, up to 10 times. The wrapper leaves those responses to client-go,
+// so that a throttled request is not retried by both, and only retries the
+// responses without a Retry-After.
+func WrapConfig(cfg *rest.Config, policy Policy) *rest.Config {
+	config := rest.CopyConfig(cfg)
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return &roundTripper{policy: policy, delegate: rt, deferRetryAfter: true}
+	})
+	return config
+}
+
+// NewRoundTripper wraps rt, retrying idempotent requests that fail with
+// one of the policy's status codes.
+func NewRoundTripper(policy Policy, rt http.RoundTripper) http.RoundTripper {
+	return &roundTripper{policy: policy, delegate: rt}
+}
+
+type roundTripper struct {
+	policy   Policy
+	delegate http.RoundTripper
+
+	// deferRetryAfter returns the responses client-go retries by itself.
+	deferRetryAfter bool
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !isIdempotent(req.Method) {
+		return rt.delegate.RoundTrip(req)
+	}
+
+	for retries := 0; ; retries++ {
+		resp, err := rt.delegate.RoundTrip(req)
+		if err != nil || retries >= rt.policy.MaxRetries || !rt.retryable(resp.StatusCode) {
+			return resp, err
+		}
+
+		if rt.deferRetryAfter && retriedByClientGo(resp) {
+			return resp, nil
+		}
+
+		delay := rt.backoff(retries)
+		if retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After")); ok {
+			if retryAfter > rt.policy.MaxRetryAfter {
+				return resp, nil
+			}
+			delay = retryAfter
+		}
+
+		if req.Body != nil && req.Body != http.NoBody {
+			if req.GetBody == nil {
+				return resp, nil
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				return resp, nil
+			}
+			req = req.Clone(req.Context())
+			req.Body = body
+		}
+
+		// drain the body so that the connection can be reused
+		_, _ = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+	}
+}
+
+func (rt *roundTripper) retryable(code int) bool {
+	for _, c := range rt.policy.StatusCodes {
+		if c == code {
+			return true
+		}
+	}
+	return false
+}
+
+func (rt *roundTripper) backoff(retries int) time.Duration {
+	delay := rt.policy.InitialBackoff
+	for i := 0; i < retries; i++ {
+		delay *= 2
+		if rt.policy.MaxBackoff > 0 && delay >= rt.policy.MaxBackoff {
+			delay = rt.policy.MaxBackoff
+			break
+		}
+	}
+	if rt.policy.Jitter > 0 {
+		delay = wait.Jitter(delay, rt.policy.Jitter)
+	}
+	return delay
+}
+
+func isIdempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// retriedByClientGo reports whether the request of client-go retries resp,
+// client-go only understands a Retry-After in seconds.
+func retriedByClientGo(resp *http.Response) bool {
+	_, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	return err == nil
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header,
+// delay-seconds and HTTP-date.
+func parseRetryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		delay := time.Until(date)
+		if delay < 0 {
+			delay = 0
+		}
+		return delay, true
+	}
+	return 0, false
+}
@@ -0,0 +1,319 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package retry_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	clusterpediav1beta1 "github.com/clusterpedia-io/api/clusterpedia/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/client"
+	"github.com/clusterpedia-io/client-go/clusterpediaclient"
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/customclient"
+	"github.com/clusterpedia-io/client-go/dynamic"
+	"github.com/clusterpedia-io/client-go/tools/retry"
+)
+
+// pediaServer is a stand-in for clusterpedia apiserver, the first failures
+// requests of every list path are rejected with status.
+type pediaServer struct {
+	*httptest.Server
+
+	status     int
+	retryAfter string
+	failures   int
+
+	lock     sync.Mutex
+	attempts map[string]int
+}
+
+func newPediaServer(t *testing.T, status, failures int, retryAfter string) *pediaServer {
+	s := &pediaServer{status: status, failures: failures, retryAfter: retryAfter, attempts: map[string]int{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.serve))
+	t.Cleanup(s.Close)
+	return s
+}
+
+func (s *pediaServer) serve(w http.ResponseWriter, r *http.Request) {
+	var body interface{}
+	switch r.URL.Path {
+	case constants.ClusterPediaAPIPath + "/api":
+		body = &metav1.APIVersions{Versions: []string{"v1"}}
+	case constants.ClusterPediaAPIPath + "/apis":
+		body = &metav1.APIGroupList{}
+	case constants.ClusterPediaAPIPath + "/api/v1":
+		body = &metav1.APIResourceList{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{{Name: "pods", Kind: "Pod", Namespaced: true, Verbs: []string{"list"}}},
+		}
+	case constants.ClusterPediaAPIPath + "/api/v1/pods":
+		body = &corev1.PodList{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "PodList"}}
+	case constants.ClusterPediaOriginAPIPath + "/collectionresources":
+		body = &clusterpediav1beta1.CollectionResourceList{TypeMeta: metav1.TypeMeta{APIVersion: "clusterpedia.io/v1beta1", Kind: "CollectionResourceList"}}
+	default:
+		http.NotFound(w, r)
+		return
+	}
+
+	if !strings.HasSuffix(r.URL.Path, "/api") && !strings.HasSuffix(r.URL.Path, "/apis") && !strings.HasSuffix(r.URL.Path, "/v1") {
+		s.lock.Lock()
+		s.attempts[r.URL.Path]++
+		attempts := s.attempts[r.URL.Path]
+		s.lock.Unlock()
+
+		if attempts <= s.failures {
+			if s.retryAfter != "" {
+				w.Header().Set("Retry-After", s.retryAfter)
+			}
+			w.WriteHeader(s.status)
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func (s *pediaServer) Attempts(path string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.attempts[path]
+}
+
+func testPolicy() retry.Policy {
+	policy := retry.DefaultPolicy()
+	policy.InitialBackoff = time.Millisecond
+	policy.MaxBackoff = 10 * time.Millisecond
+	return policy
+}
+
+func TestRoundTripper(t *testing.T) {
+	testCase := []struct {
+		name          string
+		method        string
+		status        int
+		failures      int
+		retryAfter    string
+		policy        func(*retry.Policy)
+		expectStatus  int
+		expectAttempt int
+	}{
+		{
+			name: "retry 503", method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 2,
+			expectStatus: http.StatusOK, expectAttempt: 3,
+		},
+		{
+			name: "retry 429", method: http.MethodGet, status: http.StatusTooManyRequests, failures: 1,
+			expectStatus: http.StatusOK, expectAttempt: 2,
+		},
+		{
+			name: "not retry 500", method: http.MethodGet, status: http.StatusInternalServerError, failures: 1,
+			expectStatus: http.StatusInternalServerError, expectAttempt: 1,
+		},
+		{
+			name: "not retry non-idempotent request", method: http.MethodPost, status: http.StatusServiceUnavailable, failures: 1,
+			expectStatus: http.StatusServiceUnavailable, expectAttempt: 1,
+		},
+		{
+			name: "exhaust retries", method: http.MethodGet, status: http.StatusServiceUnavailable, failures: 10,
+			policy:       func(p *retry.Policy) { p.MaxRetries = 2 },
+			expectStatus: http.StatusServiceUnavailable, expectAttempt: 3,
+		},
+		{
+			name: "retry after exceeds max", method: http.MethodGet, status: http.StatusTooManyRequests, failures: 1, retryAfter: "120",
+			expectStatus: http.StatusTooManyRequests, expectAttempt: 1,
+		},
+		{
+			name: "honour retry after", method: http.MethodGet, status: http.StatusTooManyRequests, failures: 1, retryAfter: "0",
+			expectStatus: http.StatusOK, expectAttempt: 2,
+		},
+	}
+
+	path := constants.ClusterPediaAPIPath + "/api/v1/pods"
+	for _, test := range testCase {
+		t.Run(test.name, func(t *testing.T) {
+			server := newPediaServer(t, test.status, test.failures, test.retryAfter)
+
+			policy := testPolicy()
+			if test.policy != nil {
+				test.policy(&policy)
+			}
+			c := &http.Client{Transport: retry.NewRoundTripper(policy, http.DefaultTransport)}
+
+			req, _ := http.NewRequest(test.method, server.URL+path, nil)
+			resp, err := c.Do(req)
+			if err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != test.expectStatus {
+				t.Errorf("Unexpect status: %d, expect: %d", resp.StatusCode, test.expectStatus)
+			}
+			if attempts := server.Attempts(path); attempts != test.expectAttempt {
+				t.Errorf("Unexpect attempts: %d, expect: %d", attempts, test.expectAttempt)
+			}
+		})
+	}
+}
+
+func TestRoundTripperContextCanceled(t *testing.T) {
+	server := newPediaServer(t, http.StatusServiceUnavailable, 10, "")
+
+	policy := testPolicy()
+	policy.InitialBackoff = time.Minute
+	policy.MaxBackoff = time.Minute
+	c := &http.Client{Transport: retry.NewRoundTripper(policy, http.DefaultTransport)}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+constants.ClusterPediaAPIPath+"/api/v1/pods", nil)
+	if _, err := c.Do(req); err == nil {
+		t.Fatalf("Expect error when context is canceled")
+	}
+}
+
+func TestClients(t *testing.T) {
+	ctx := context.TODO()
+	podsPath := constants.ClusterPediaAPIPath + "/api/v1/pods"
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	testCase := []struct {
+		name string
+		path string
+		list func(config *rest.Config) error
+	}{
+		{
+			name: "client",
+			path: podsPath,
+			list: func(config *rest.Config) error {
+				c, err := client.GetClient(config)
+				if err != nil {
+					return err
+				}
+				return c.List(ctx, &corev1.PodList{})
+			},
+		},
+		{
+			name: "dynamic",
+			path: podsPath,
+			list: func(config *rest.Config) error {
+				dc, err := dynamic.NewForConfig(config)
+				if err != nil {
+					return err
+				}
+				_, err = dc.Resource(podsGVR).List(ctx, metav1.ListOptions{})
+				return err
+			},
+		},
+		{
+			name: "customclient",
+			path: podsPath,
+			list: func(config *rest.Config) error {
+				cc, err := customclient.NewForConfig(config)
+				if err != nil {
+					return err
+				}
+				return cc.Resource(podsGVR).List(ctx, metav1.ListOptions{}, nil, &corev1.PodList{})
+			},
+		},
+		{
+			name: "clusterpediaclient",
+			path: constants.ClusterPediaOriginAPIPath + "/collectionresources",
+			list: func(config *rest.Config) error {
+				cc, err := clusterpediaclient.NewForConfig(config)
+				if err != nil {
+					return err
+				}
+				_, err = cc.PediaClusterV1beta1().CollectionResource().List(ctx, metav1.ListOptions{})
+				return err
+			},
+		},
+	}
+
+	for _, test := range testCase {
+		t.Run(test.name, func(t *testing.T) {
+			server := newPediaServer(t, http.StatusServiceUnavailable, 2, "")
+			config := retry.WrapConfig(&rest.Config{Host: server.URL}, testPolicy())
+
+			if err := test.list(config); err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			if attempts := server.Attempts(test.path); attempts != 3 {
+				t.Errorf("Unexpect attempts: %d, expect: %d", attempts, 3)
+			}
+		})
+	}
+}
+
+func TestWrapConfigRetryAfter(t *testing.T) {
+	path := constants.ClusterPediaAPIPath + "/api/v1/pods"
+	podsGVR := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	testCase := []struct {
+		name          string
+		failures      int
+		retryAfter    string
+		expectErr     bool
+		expectAttempt int
+	}{
+		{
+			// the retries of client-go only, not multiplied by the policy
+			name: "retry after in seconds", failures: 100, retryAfter: "0",
+			expectErr: true, expectAttempt: 11,
+		},
+		{
+			name: "retry after in seconds recovers", failures: 2, retryAfter: "0",
+			expectAttempt: 3,
+		},
+		{
+			// client-go does not understand the HTTP-date form
+			name: "retry after as date", failures: 2, retryAfter: time.Now().UTC().Format(http.TimeFormat),
+			expectAttempt: 3,
+		},
+	}
+	for _, test := range testCase {
+		t.Run(test.name, func(t *testing.T) {
+			server := newPediaServer(t, http.StatusTooManyRequests, test.failures, test.retryAfter)
+			config := retry.WrapConfig(&rest.Config{Host: server.URL}, testPolicy())
+
+			dc, err := dynamic.NewForConfig(config)
+			if err != nil {
+				t.Fatalf("Unexpect error: %v", err)
+			}
+			_, err = dc.Resource(podsGVR).List(context.TODO(), metav1.ListOptions{})
+			if (err != nil) != test.expectErr {
+				t.Errorf("Unexpect error: %v", err)
+			}
+			if attempts := server.Attempts(path); attempts != test.expectAttempt {
+				t.Errorf("Unexpect attempts: %d, expect: %d", attempts, test.expectAttempt)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shadow
+
+import (
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+// ClusterOf returns the cluster of an object returned by clusterpedia.
+func ClusterOf(obj metav1.Object) string {
+	return obj.GetAnnotations()[constants.ShadowAnnotationClusterName]
+}
+
+// SetCluster records the cluster of the object in its annotations.
+func SetCluster(obj metav1.Object, cluster string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 1)
+	}
+	annotations[constants.ShadowAnnotationClusterName] = cluster
+	obj.SetAnnotations(annotations)
+}
+
+// GVROf returns the GroupVersionResource the object was synchronized as.
+// Both "apps/v1, Resource=deployments" and "apps/v1/deployments" are accepted.
+func GVROf(obj metav1.Object) (schema.GroupVersionResource, bool) {
+	return ParseGVR(obj.GetAnnotations()[constants.ShadowAnnotationGroupVersionResource])
+}
+
+// ParseGVR parses the value of the shadow.clusterpedia.io/gvr annotation.
+func ParseGVR(value string) (schema.GroupVersionResource, bool) {
+	if value == "" {
+		return schema.GroupVersionResource{}, false
+	}
+
+	if gv, resource, ok := strings.Cut(value, ", Resource="); ok {
+		groupVersion, err := schema.ParseGroupVersion(gv)
+		if err != nil || resource == "" {
+			return schema.GroupVersionResource{}, false
+		}
+		return groupVersion.WithResource(resource), true
+	}
+
+	parts := strings.Split(value, "/")
+	switch len(parts) {
+	case 2:
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, parts[0] != "" && parts[1] != ""
+	case 3:
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, parts[1] != "" && parts[2] != ""
+	}
+	return schema.GroupVersionResource{}, false
+}
+
+// ClusterObjectKey identifies an object across clusters.
+type ClusterObjectKey struct {
+	Cluster   string
+	Namespace string
+	Name      string
+}
+
+func (k ClusterObjectKey) String() string {
+	if k.Namespace == "" {
+		return k.Cluster + "/" + k.Name
+	}
+	return k.Cluster + "/" + k.Namespace + "/" + k.Name
+}
+
+// KeyOf returns the cluster aware key of the object.
+func KeyOf(obj metav1.Object) ClusterObjectKey {
+	return ClusterObjectKey{Cluster: ClusterOf(obj), Namespace: obj.GetNamespace(), Name: obj.GetName()}
+}
+
+// Dedup returns objs without the objects whose key has already appeared,
+// the first object of every key is kept.
+func Dedup(objs []runtime.Object) ([]runtime.Object, error) {
+	seen := make(map[ClusterObjectKey]struct{}, len(objs))
+	deduped := make([]runtime.Object, 0, len(objs))
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		key := KeyOf(accessor)
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		deduped = append(deduped, obj)
+	}
+	return deduped, nil
+}
+
+// DedupList removes the duplicated items of a list, such as the overlapping
+// items of pages fetched while the data is changing.
+func DedupList(list runtime.Object) error {
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	if objs, err = Dedup(objs); err != nil {
+		return err
+	}
+	return meta.SetList(list, objs)
+}
+
+// GroupByCluster groups the objects by their cluster, keeping their order.
+func GroupByCluster(objs []runtime.Object) (map[string][]runtime.Object, error) {
+	groups := make(map[string][]runtime.Object)
+	for _, obj := range objs {
+		accessor, err := meta.Accessor(obj)
+		if err != nil {
+			return nil, err
+		}
+
+		cluster := ClusterOf(accessor)
+		groups[cluster] = append(groups[cluster], obj)
+	}
+	return groups, nil
+}
+
+// GroupListByCluster groups the items of a list by their cluster.
+func GroupListByCluster(list runtime.Object) (map[string][]runtime.Object, error) {
+	objs, err := meta.ExtractList(list)
+	if err != nil {
+		return nil, err
+	}
+	return GroupByCluster(objs)
+}
+
+// StripAnnotations removes the shadow.clusterpedia.io annotations, which must
+// not be written back to the member cluster, and returns the removed ones.
+// The annotations map of obj is replaced rather than modified.
+func StripAnnotations(obj metav1.Object) map[string]string {
+	var annotations, stripped map[string]string
+	for key, value := range obj.GetAnnotations() {
+		if strings.HasPrefix(key, constants.ShadowAnnotationPrefix) {
+			if stripped == nil {
+				stripped = make(map[string]string)
+			}
+			stripped[key] = value
+			continue
+		}
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+		annotations[key] = value
+	}
+	obj.SetAnnotations(annotations)
+	return stripped
+}
+
+// RestoreAnnotations sets the annotations returned by StripAnnotations again.
+func RestoreAnnotations(obj metav1.Object, stripped map[string]string) {
+	if len(stripped) == 0 {
+		return
+	}
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, len(stripped))
+	}
+	for key, value := range stripped {
+		annotations[key] = value
+	}
+	obj.SetAnnotations(annotations)
+}
+
+// StripForCreate removes the shadow annotations and the metadata populated by
+// the server, so that an object read from clusterpedia can be created in a
+// member cluster.
+func StripForCreate(obj metav1.Object) {
+	StripAnnotations(obj)
+	obj.SetUID("")
+	obj.SetResourceVersion("")
+	obj.SetGeneration(0)
+	obj.SetCreationTimestamp(metav1.Time{})
+	obj.SetDeletionTimestamp(nil)
+	obj.SetDeletionGracePeriodSeconds(nil)
+	obj.SetManagedFields(nil)
+	obj.SetSelfLink("")
+}
@@ -0,0 +1,129 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package shadow
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/clusterpedia-io/client-go/constants"
+)
+
+func TestParseGVR(t *testing.T) {
+	testCase := []struct {
+		value     string
+		expectGVR schema.GroupVersionResource
+		expectOK  bool
+	}{
+		{"apps/v1, Resource=deployments", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true},
+		{"/v1, Resource=pods", schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true},
+		{"apps/v1/deployments", schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}, true},
+		{"v1/pods", schema.GroupVersionResource{Version: "v1", Resource: "pods"}, true},
+		{"pods", schema.GroupVersionResource{}, false},
+		{"", schema.GroupVersionResource{}, false},
+	}
+
+	for _, test := range testCase {
+		t.Run(test.value, func(t *testing.T) {
+			gvr, ok := ParseGVR(test.value)
+			if ok != test.expectOK || gvr != test.expectGVR {
+				t.Errorf("Unexpect gvr: %v %v, expect: %v %v", gvr, ok, test.expectGVR, test.expectOK)
+			}
+		})
+	}
+}
+
+func newPod(cluster, namespace, name string) *corev1.Pod {
+	return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace: namespace,
+		Name:      name,
+		Annotations: map[string]string{
+			constants.ShadowAnnotationClusterName:          cluster,
+			constants.ShadowAnnotationGroupVersionResource: "/v1, Resource=pods",
+		},
+	}}
+}
+
+func TestDedupList(t *testing.T) {
+	list := &corev1.PodList{Items: []corev1.Pod{
+		*newPod("cluster-01", "default", "pod-a"),
+		*newPod("cluster-02", "default", "pod-a"),
+		*newPod("cluster-01", "default", "pod-a"),
+		*newPod("cluster-01", "kube-system", "pod-a"),
+	}}
+	if err := DedupList(list); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	expect := []string{"cluster-01/default/pod-a", "cluster-02/default/pod-a", "cluster-01/kube-system/pod-a"}
+	if len(list.Items) != len(expect) {
+		t.Fatalf("Unexpect items: %d, expect: %d", len(list.Items), len(expect))
+	}
+	for i := range list.Items {
+		if key := KeyOf(&list.Items[i]).String(); key != expect[i] {
+			t.Errorf("Unexpect item: %s, expect: %s", key, expect[i])
+		}
+	}
+
+	groups, err := GroupListByCluster(list)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if len(groups["cluster-01"]) != 2 || len(groups["cluster-02"]) != 1 {
+		t.Errorf("Unexpect groups: %v", groups)
+	}
+}
+
+func TestStripAnnotations(t *testing.T) {
+	pod := newPod("cluster-01", "default", "pod-a")
+	pod.Annotations[constants.ShadowAnnotationGroupVersionResource] = "v1/pods"
+	pod.Annotations["owner"] = "team-a"
+	annotations := pod.Annotations
+
+	stripped := StripAnnotations(pod)
+	if len(pod.Annotations) != 1 || pod.Annotations["owner"] != "team-a" {
+		t.Errorf("Unexpect annotations: %v", pod.Annotations)
+	}
+	if len(stripped) != 2 || len(annotations) != 3 {
+		t.Errorf("Unexpect stripped annotations: %v, the original annotations: %v", stripped, annotations)
+	}
+
+	pod.Annotations = nil
+	RestoreAnnotations(pod, stripped)
+	if ClusterOf(pod) != "cluster-01" || pod.Annotations[constants.ShadowAnnotationGroupVersionResource] != "v1/pods" {
+		t.Errorf("Unexpect restored annotations: %v", pod.Annotations)
+	}
+}
+
+func TestStripForCreate(t *testing.T) {
+	pod := newPod("cluster-01", "default", "pod-a")
+	pod.Annotations["owner"] = "team-a"
+	pod.UID = "8f6c1d2e"
+	pod.ResourceVersion = "100"
+	pod.CreationTimestamp = metav1.Now()
+
+	StripForCreate(pod)
+	if ClusterOf(pod) != "" || len(pod.Annotations) != 1 {
+		t.Errorf("Unexpect annotations: %v", pod.Annotations)
+	}
+	if pod.UID != "" || pod.ResourceVersion != "" || !pod.CreationTimestamp.IsZero() {
+		t.Errorf("Unexpect metadata: %v", pod.ObjectMeta)
+	}
+}
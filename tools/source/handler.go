@@ -0,0 +1,80 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+// RequestFor returns the request of an object in a member cluster, the cluster
+// is encoded in the name of the request as <cluster>/<name>.
+func RequestFor(key shadow.ClusterObjectKey) reconcile.Request {
+	return reconcile.Request{NamespacedName: types.NamespacedName{
+		Namespace: key.Namespace,
+		Name:      key.Cluster + "/" + key.Name,
+	}}
+}
+
+// ParseRequest returns the object key encoded in a request by RequestFor.
+// The cluster can be passed to the routing client with client.WithCluster.
+func ParseRequest(req reconcile.Request) shadow.ClusterObjectKey {
+	cluster, name, ok := strings.Cut(req.Name, "/")
+	if !ok {
+		return shadow.ClusterObjectKey{Namespace: req.Namespace, Name: req.Name}
+	}
+	return shadow.ClusterObjectKey{Cluster: cluster, Namespace: req.Namespace, Name: name}
+}
+
+var _ handler.EventHandler = &EnqueueRequestForClusterObject{}
+
+// EnqueueRequestForClusterObject enqueues the request of the object with its
+// cluster encoded by RequestFor.
+type EnqueueRequestForClusterObject struct{}
+
+func (e *EnqueueRequestForClusterObject) Create(ctx context.Context, evt event.CreateEvent, q workqueue.RateLimitingInterface) {
+	enqueue(evt.Object, q)
+}
+
+func (e *EnqueueRequestForClusterObject) Update(ctx context.Context, evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+	enqueue(evt.ObjectOld, q)
+	enqueue(evt.ObjectNew, q)
+}
+
+func (e *EnqueueRequestForClusterObject) Delete(ctx context.Context, evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+	enqueue(evt.Object, q)
+}
+
+func (e *EnqueueRequestForClusterObject) Generic(ctx context.Context, evt event.GenericEvent, q workqueue.RateLimitingInterface) {
+	enqueue(evt.Object, q)
+}
+
+func enqueue(obj client.Object, q workqueue.RateLimitingInterface) {
+	if obj == nil {
+		return
+	}
+	q.Add(RequestFor(shadow.KeyOf(obj)))
+}
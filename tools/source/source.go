@@ -0,0 +1,169 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+
+	"github.com/clusterpedia-io/client-go/tools/builder"
+	"github.com/clusterpedia-io/client-go/tools/listwatch"
+)
+
+var _ source.SyncingSource = &Kind{}
+
+// Kind is a controller-runtime source of the changes of a clusterpedia search,
+// the objects of the member clusters are polled with listwatch.ListWatch. The
+// objects are cached by cluster, namespace and name, so the objects with the
+// same name in different clusters get their own events.
+type Kind struct {
+	obj          client.Object
+	lw           *listwatch.ListWatch
+	resyncPeriod time.Duration
+
+	lock       sync.Mutex
+	controller cache.Controller
+	started    chan struct{}
+}
+
+// NewKind returns a source of the objects found by the search built by query,
+// obj and list are the object and the list types of the search, such as
+// &appsv1.Deployment{} and &appsv1.DeploymentList{}.
+func NewKind(c client.Client, obj client.Object, list client.ObjectList, query builder.ListOptionsInterface, opts listwatch.Options) *Kind {
+	return &Kind{
+		obj:     obj,
+		lw:      listwatch.New(c, list, query, opts),
+		started: make(chan struct{}),
+	}
+}
+
+// WithResyncPeriod sets the period of replaying all the objects as update events.
+func (k *Kind) WithResyncPeriod(period time.Duration) *Kind {
+	k.resyncPeriod = period
+	return k
+}
+
+// Start starts polling the search and passes the events to the handler,
+// the source can only be started once.
+func (k *Kind) Start(ctx context.Context, h handler.EventHandler, queue workqueue.RateLimitingInterface, prct ...predicate.Predicate) error {
+	k.lock.Lock()
+	defer k.lock.Unlock()
+	if k.controller != nil {
+		return fmt.Errorf("%s has already been started", k)
+	}
+
+	_, k.controller = listwatch.NewIndexerInformer(k.lw.WithContext(ctx), k.obj, k.resyncPeriod, &eventHandler{
+		ctx:        ctx,
+		handler:    h,
+		queue:      queue,
+		predicates: prct,
+	}, nil)
+	go k.controller.Run(ctx.Done())
+	close(k.started)
+	return nil
+}
+
+// WaitForSync blocks until the first listing of the search is handled.
+func (k *Kind) WaitForSync(ctx context.Context) error {
+	select {
+	case <-k.started:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	if !cache.WaitForCacheSync(ctx.Done(), k.controller.HasSynced) {
+		return errors.New("timed out waiting for clusterpedia search to sync")
+	}
+	return nil
+}
+
+func (k *Kind) String() string {
+	return fmt.Sprintf("clusterpedia source: %T", k.obj)
+}
+
+// eventHandler passes the informer events to the controller-runtime handler.
+type eventHandler struct {
+	ctx        context.Context
+	handler    handler.EventHandler
+	queue      workqueue.RateLimitingInterface
+	predicates []predicate.Predicate
+}
+
+func (e *eventHandler) OnAdd(obj interface{}, isInInitialList bool) {
+	o, ok := obj.(client.Object)
+	if !ok {
+		return
+	}
+
+	evt := event.CreateEvent{Object: o}
+	for _, p := range e.predicates {
+		if !p.Create(evt) {
+			return
+		}
+	}
+	e.handler.Create(e.ctx, evt, e.queue)
+}
+
+func (e *eventHandler) OnUpdate(oldObj, newObj interface{}) {
+	oldO, ok := oldObj.(client.Object)
+	if !ok {
+		return
+	}
+	newO, ok := newObj.(client.Object)
+	if !ok {
+		return
+	}
+
+	evt := event.UpdateEvent{ObjectOld: oldO, ObjectNew: newO}
+	for _, p := range e.predicates {
+		if !p.Update(evt) {
+			return
+		}
+	}
+	e.handler.Update(e.ctx, evt, e.queue)
+}
+
+func (e *eventHandler) OnDelete(obj interface{}) {
+	var evt event.DeleteEvent
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		evt.DeleteStateUnknown = true
+		obj = tombstone.Obj
+	}
+
+	o, ok := obj.(client.Object)
+	if !ok {
+		return
+	}
+	evt.Object = o
+	for _, p := range e.predicates {
+		if !p.Delete(evt) {
+			return
+		}
+	}
+	e.handler.Delete(e.ctx, evt, e.queue)
+}
@@ -0,0 +1,196 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package source
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+	"github.com/clusterpedia-io/client-go/tools/listwatch"
+	"github.com/clusterpedia-io/client-go/tools/shadow"
+)
+
+type podsClient struct {
+	client.Client
+
+	lock sync.Mutex
+	pods []corev1.Pod
+}
+
+func (c *podsClient) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	list.(*corev1.PodList).Items = append([]corev1.Pod(nil), c.pods...)
+	return nil
+}
+
+func (c *podsClient) set(pods ...corev1.Pod) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.pods = pods
+}
+
+func newPod(cluster, name, uid string) corev1.Pod {
+	return corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Namespace:       "default",
+		Name:            name,
+		UID:             types.UID(uid),
+		ResourceVersion: "1",
+		Annotations:     map[string]string{constants.ShadowAnnotationClusterName: cluster},
+	}}
+}
+
+func TestRequest(t *testing.T) {
+	tests := []shadow.ClusterObjectKey{
+		{Cluster: "cluster-01", Namespace: "default", Name: "pod-a"},
+		{Cluster: "cluster-01", Name: "node-a"},
+	}
+	for _, key := range tests {
+		if got := ParseRequest(RequestFor(key)); got != key {
+			t.Errorf("Unexpect key: %v, expect: %v", got, key)
+		}
+	}
+
+	key := ParseRequest(reconcile.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "pod-a"}})
+	if key.Cluster != "" || key.Name != "pod-a" {
+		t.Errorf("Unexpect key of request without cluster: %v", key)
+	}
+}
+
+func TestKind(t *testing.T) {
+	c := &podsClient{}
+	c.set(newPod("cluster-01", "pod-a", "uid-1"), newPod("cluster-02", "pod-a", "uid-2"))
+	kind := NewKind(c, &corev1.Pod{}, &corev1.PodList{}, builder.ListOptionsBuilder(), listwatch.Options{Interval: 10 * time.Millisecond})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	if err := kind.Start(ctx, &EnqueueRequestForClusterObject{}, queue); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if err := kind.Start(ctx, &EnqueueRequestForClusterObject{}, queue); err == nil {
+		t.Errorf("Expect error of starting twice")
+	}
+	if err := kind.WaitForSync(ctx); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	got := make(map[shadow.ClusterObjectKey]bool)
+	for len(got) < 2 {
+		item, _ := queue.Get()
+		got[ParseRequest(item.(reconcile.Request))] = true
+		queue.Done(item)
+	}
+	for _, cluster := range []string{"cluster-01", "cluster-02"} {
+		if key := (shadow.ClusterObjectKey{Cluster: cluster, Namespace: "default", Name: "pod-a"}); !got[key] {
+			t.Errorf("Request of %s is not enqueued", key)
+		}
+	}
+
+	c.set(newPod("cluster-01", "pod-a", "uid-1"))
+	done := make(chan reconcile.Request)
+	go func() {
+		item, _ := queue.Get()
+		done <- item.(reconcile.Request)
+	}()
+	select {
+	case req := <-done:
+		if key := ParseRequest(req); key.Cluster != "cluster-02" {
+			t.Errorf("Unexpect request of deleted pod: %v", key)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("Timeout waiting for request of deleted pod")
+	}
+}
+
+func TestKindSameNameInClusters(t *testing.T) {
+	c := &podsClient{}
+	c.set(newPod("cluster-01", "pod-a", "uid-1"), newPod("cluster-02", "pod-a", "uid-2"))
+	kind := NewKind(c, &corev1.Pod{}, &corev1.PodList{}, builder.ListOptionsBuilder(), listwatch.Options{Interval: 10 * time.Millisecond}).
+		WithResyncPeriod(20 * time.Millisecond)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	var lock sync.Mutex
+	deleted := make(map[string]int)
+	resynced := make(map[string]int)
+	h := handler.Funcs{
+		UpdateFunc: func(ctx context.Context, evt event.UpdateEvent, q workqueue.RateLimitingInterface) {
+			lock.Lock()
+			defer lock.Unlock()
+			resynced[shadow.ClusterOf(evt.ObjectNew)]++
+		},
+		DeleteFunc: func(ctx context.Context, evt event.DeleteEvent, q workqueue.RateLimitingInterface) {
+			lock.Lock()
+			defer lock.Unlock()
+			deleted[shadow.ClusterOf(evt.Object)]++
+		},
+	}
+	if err := kind.Start(ctx, h, queue); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if err := kind.WaitForSync(ctx); err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	// pod-a is deleted from cluster-02 only
+	c.set(newPod("cluster-01", "pod-a", "uid-1"))
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return deleted["cluster-02"] == 1, nil
+	}); err != nil {
+		t.Fatalf("Timeout waiting for the delete event of cluster-02")
+	}
+
+	// the pod of cluster-01 is still resynced from the cache
+	lock.Lock()
+	resynced["cluster-01"] = 0
+	lock.Unlock()
+	if err := wait.PollUntilContextTimeout(ctx, 10*time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return resynced["cluster-01"] >= 2, nil
+	}); err != nil {
+		t.Errorf("Expect pod-a of cluster-01 is kept in the cache")
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	if deleted["cluster-01"] != 0 || deleted["cluster-02"] != 1 {
+		t.Errorf("Unexpect delete events: %v", deleted)
+	}
+}
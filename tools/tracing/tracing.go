@@ -0,0 +1,358 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/transport"
+
+	"github.com/clusterpedia-io/client-go/constants"
+	"github.com/clusterpedia-io/client-go/tools/requestinfo"
+)
+
+const instrumentationName = "github.com/clusterpedia-io/client-go/tools/tracing"
+
+// the attributes of the clusterpedia requests
+const (
+	ClusterKey        = attribute.Key("clusterpedia.cluster")
+	ProxyKey          = attribute.Key("clusterpedia.proxy")
+	ResourceKey       = attribute.Key("clusterpedia.resource")
+	NamespaceKey      = attribute.Key("clusterpedia.namespace")
+	NameKey           = attribute.Key("clusterpedia.name")
+	LimitKey          = attribute.Key("clusterpedia.limit")
+	OffsetKey         = attribute.Key("clusterpedia.offset")
+	ContinueKey       = attribute.Key("clusterpedia.continue")
+	ItemCountKey      = attribute.Key("clusterpedia.item_count")
+	RemainingCountKey = attribute.Key("clusterpedia.remaining_count")
+
+	// SearchKeyPrefix prefixes the search terms, such as clusterpedia.search.clusters
+	// for search.clusterpedia.io/clusters.
+	SearchKeyPrefix = "clusterpedia.search."
+)
+
+type Options struct {
+	// TracerProvider defaults to the global provider of otel.
+	TracerProvider trace.TracerProvider
+
+	// Propagator injects the trace context into the request headers,
+	// defaults to the global propagator of otel.
+	Propagator propagation.TextMapPropagator
+}
+
+// WrapConfig returns a copy of cfg whose transport creates a span for every
+// request and propagates the trace context to clusterpedia apiserver. Every
+// client of this module built from the returned config, such as
+// client.GetClient, dynamic.NewForConfig, customclient.NewForConfig and
+// clusterpediaclient.NewForConfig, is traced.
+func WrapConfig(cfg *rest.Config, opts Options) *rest.Config {
+	config := rest.CopyConfig(cfg)
+	config.WrapTransport = transport.Wrappers(config.WrapTransport, func(rt http.RoundTripper) http.RoundTripper {
+		return NewRoundTripper(opts, rt)
+	})
+	return config
+}
+
+// NewRoundTripper wraps rt, creating a span for every request.
+func NewRoundTripper(opts Options, rt http.RoundTripper) http.RoundTripper {
+	provider := opts.TracerProvider
+	if provider == nil {
+		provider = otel.GetTracerProvider()
+	}
+	propagator := opts.Propagator
+	if propagator == nil {
+		propagator = otel.GetTextMapPropagator()
+	}
+	return &roundTripper{
+		tracer:     provider.Tracer(instrumentationName),
+		propagator: propagator,
+		delegate:   rt,
+	}
+}
+
+type roundTripper struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+	delegate   http.RoundTripper
+}
+
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	info := requestinfo.New(req)
+	ctx, span := rt.tracer.Start(req.Context(), spanName(info),
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attributesOf(req, info)...),
+	)
+
+	req = req.Clone(ctx)
+	rt.propagator.Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := rt.delegate.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		span.End()
+		return resp, err
+	}
+
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusBadRequest {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+	if info.Verb == "list" && resp.StatusCode == http.StatusOK && isJSON(resp.Header.Get("Content-Type")) {
+		// the span of a list ends once its body is read and closed
+		resp.Body = &listBody{ReadCloser: resp.Body, span: span}
+		return resp, nil
+	}
+	span.End()
+	return resp, nil
+}
+
+func spanName(info *requestinfo.RequestInfo) string {
+	if info.Resource.Resource == "" {
+		return "clusterpedia " + info.Verb
+	}
+	resource := info.Resource.Resource
+	if info.Subresource != "" {
+		resource += "/" + info.Subresource
+	}
+	return fmt.Sprintf("clusterpedia %s %s", info.Verb, resource)
+}
+
+func attributesOf(req *http.Request, info *requestinfo.RequestInfo) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{
+		semconv.HTTPRequestMethodKey.String(req.Method),
+		semconv.ServerAddress(req.URL.Hostname()),
+		attribute.String("url.path", req.URL.Path),
+		ResourceKey.String(info.Resource.String()),
+	}
+	if info.Cluster != "" {
+		attrs = append(attrs, ClusterKey.String(info.Cluster))
+	}
+	if info.Proxy {
+		attrs = append(attrs, ProxyKey.Bool(true))
+	}
+	if info.Namespace != "" {
+		attrs = append(attrs, NamespaceKey.String(info.Namespace))
+	}
+	if info.Name != "" {
+		attrs = append(attrs, NameKey.String(info.Name))
+	}
+
+	query := req.URL.Query()
+	if limit, err := strconv.ParseInt(query.Get("limit"), 10, 64); err == nil {
+		attrs = append(attrs, LimitKey.Int64(limit))
+	}
+	if c := query.Get("continue"); c != "" {
+		// the continue of a clusterpedia search is the offset of the page
+		if offset, err := strconv.ParseInt(c, 10, 64); err == nil && info.Search {
+			attrs = append(attrs, OffsetKey.Int64(offset))
+		} else {
+			attrs = append(attrs, ContinueKey.String(c))
+		}
+	}
+	if info.Search {
+		attrs = append(attrs, searchAttributes(query.Get("labelSelector"))...)
+	}
+	return attrs
+}
+
+// searchAttributes decodes the search terms set by the builder in the label selector.
+func searchAttributes(selector string) []attribute.KeyValue {
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil
+	}
+
+	var attrs []attribute.KeyValue
+	requirements, _ := parsed.Requirements()
+	for _, requirement := range requirements {
+		key := requirement.Key()
+		values := requirement.Values().List()
+		switch key {
+		case constants.SearchLabelLimit:
+			if limit, err := strconv.ParseInt(firstOf(values), 10, 64); err == nil {
+				attrs = append(attrs, LimitKey.Int64(limit))
+			}
+		case constants.SearchLabelOffset:
+			if offset, err := strconv.ParseInt(firstOf(values), 10, 64); err == nil {
+				attrs = append(attrs, OffsetKey.Int64(offset))
+			}
+		default:
+			_, name, ok := strings.Cut(key, "clusterpedia.io/")
+			if !ok {
+				continue
+			}
+			name = strings.ReplaceAll(name, "-", "_")
+			attrs = append(attrs, attribute.StringSlice(SearchKeyPrefix+name, values))
+		}
+	}
+	return attrs
+}
+
+func firstOf(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
+
+func isJSON(contentType string) bool {
+	return strings.HasPrefix(contentType, "application/json")
+}
+
+// listBody counts the items of a list response while the caller reads it,
+// the counts and the size are recorded and the span is ended on Close.
+type listBody struct {
+	io.ReadCloser
+	span trace.Span
+
+	counter listCounter
+	size    int
+	eof     bool
+	once    sync.Once
+}
+
+func (b *listBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.size += n
+	b.counter.scan(p[:n])
+	if err == io.EOF {
+		b.eof = true
+	}
+	return n, err
+}
+
+func (b *listBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(func() {
+		b.span.SetAttributes(semconv.HTTPResponseBodySize(b.size))
+		// the counts of a partially read list are not known
+		if b.eof && b.counter.complete() {
+			b.span.SetAttributes(ItemCountKey.Int(b.counter.items))
+			if b.counter.remaining != "" {
+				if remaining, err := strconv.ParseInt(b.counter.remaining, 10, 64); err == nil {
+					b.span.SetAttributes(RemainingCountKey.Int64(remaining))
+				}
+			}
+		}
+		b.span.End()
+	})
+	return err
+}
+
+// listCounter scans a JSON list incrementally, counting the objects of the
+// top-level items and reading metadata.remainingItemCount, without buffering
+// the list.
+type listCounter struct {
+	depth    int
+	started  bool
+	inString bool
+	escaped  bool
+
+	// str is the head of the last string at depth 1 or 2, key1 and key2
+	// are the last keys at these depths
+	str        []byte
+	key1, key2 string
+
+	// section is the key of the container of depth 2
+	section string
+	number  bool
+
+	items     int
+	remaining string
+}
+
+const maxKeyLength = 32
+
+func (c *listCounter) scan(data []byte) {
+	for _, b := range data {
+		if c.inString {
+			switch {
+			case c.escaped:
+				c.escaped = false
+			case b == '\\':
+				c.escaped = true
+			case b == '"':
+				c.inString = false
+				continue
+			}
+			if len(c.str) < maxKeyLength {
+				c.str = append(c.str, b)
+			}
+			continue
+		}
+
+		if c.number {
+			switch {
+			case b >= '0' && b <= '9' || b == '-':
+				c.remaining += string(b)
+				continue
+			case c.remaining == "" && (b == ' ' || b == '\t' || b == '\r' || b == '\n'):
+				continue
+			}
+			c.number = false
+		}
+
+		switch b {
+		case '"':
+			c.inString, c.str = true, c.str[:0]
+		case ':':
+			switch c.depth {
+			case 1:
+				c.key1 = string(c.str)
+			case 2:
+				c.key2 = string(c.str)
+				if c.section == "metadata" && c.key2 == "remainingItemCount" {
+					c.number, c.remaining = true, ""
+				}
+			}
+		case '{', '[':
+			switch c.depth {
+			case 1:
+				c.section = ""
+				if b == '[' && c.key1 == "items" || b == '{' && c.key1 == "metadata" {
+					c.section = c.key1
+				}
+			case 2:
+				if b == '{' && c.section == "items" {
+					c.items++
+				}
+			}
+			c.depth++
+			c.started = true
+		case '}', ']':
+			c.depth--
+		}
+	}
+}
+
+func (c *listCounter) complete() bool {
+	return c.started && c.depth == 0 && !c.inString
+}
@@ -0,0 +1,171 @@
+/*
+Copyright 2021 clusterpedia Authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+     http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package tracing
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
+
+	"github.com/clusterpedia-io/client-go/client"
+	"github.com/clusterpedia-io/client-go/tools/builder"
+)
+
+const podList = `{"kind":"PodList","apiVersion":"v1","metadata":{"remainingItemCount":8},"items":[{"metadata":{"name":"pod-a","namespace":"default"}},{"metadata":{"name":"pod-b","namespace":"default"}}]}`
+
+func TestWrapConfig(t *testing.T) {
+	traceparents := make(chan string, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparents <- r.Header.Get("traceparent")
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(podList))
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	config, err := client.ClusterConfigFor(WrapConfig(&rest.Config{Host: server.URL}, Options{
+		TracerProvider: provider,
+		Propagator:     propagation.TraceContext{},
+	}), "cluster-01")
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	dc, err := dynamic.NewForConfig(config)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+
+	query := builder.ListOptionsBuilder().Namespaces("default", "kube-system").Limit(2).Offset(4).RemainingCount().Options()
+	list, err := dc.Resource(schema.GroupVersionResource{Version: "v1", Resource: "pods"}).List(context.TODO(), query)
+	if err != nil {
+		t.Fatalf("Unexpect error: %v", err)
+	}
+	if len(list.Items) != 2 {
+		t.Errorf("Unexpect items: %d, expect: %d", len(list.Items), 2)
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("Unexpect spans: %d, expect: %d", len(spans), 1)
+	}
+	span := spans[0]
+	if span.Name() != "clusterpedia list pods" {
+		t.Errorf("Unexpect span name: %s", span.Name())
+	}
+	if traceparent := <-traceparents; traceparent == "" || traceparent[3:35] != span.SpanContext().TraceID().String() {
+		t.Errorf("Unexpect traceparent: %q", traceparent)
+	}
+
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value
+	}
+	expect := map[attribute.Key]string{
+		ClusterKey:                               "cluster-01",
+		LimitKey:                                 "2",
+		OffsetKey:                                "4",
+		ItemCountKey:                             "2",
+		RemainingCountKey:                        "8",
+		SearchKeyPrefix + "namespaces":           "[default kube-system]",
+		SearchKeyPrefix + "with_remaining_count": "[true]",
+	}
+	for key, value := range expect {
+		if got := attrs[key].Emit(); got != value {
+			t.Errorf("Unexpect attribute %s: %q, expect: %q", key, got, value)
+		}
+	}
+}
+
+func TestListCounter(t *testing.T) {
+	testCase := []struct {
+		name      string
+		body      string
+		items     int
+		remaining string
+	}{
+		{name: "list", body: podList, items: 2, remaining: "8"},
+		{name: "empty list", body: `{"kind":"PodList","metadata":{},"items":[]}`},
+		{
+			name:  "nested items and strings",
+			body:  `{"metadata":{"continue":"{\"items\":[{}]}"},"items":[{"metadata":{"name":"a]}"},"spec":{"items":[{},{}]}},{"data":{"remainingItemCount":"1"}}],"remainingItemCount":3}`,
+			items: 2,
+		},
+		{name: "remaining count after items", body: `{"items":[{}], "metadata" : { "remainingItemCount" : 12 }}`, items: 1, remaining: "12"},
+	}
+	for _, tc := range testCase {
+		// scan the body byte by byte to cross every boundary
+		var counter listCounter
+		for i := range tc.body {
+			counter.scan([]byte{tc.body[i]})
+		}
+		if !counter.complete() || counter.items != tc.items || counter.remaining != tc.remaining {
+			t.Errorf("%s: Unexpect counts: %d items, %q remaining, expect: %d items, %q remaining", tc.name, counter.items, counter.remaining, tc.items, tc.remaining)
+		}
+	}
+}
+
+func TestListBody(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+
+	for _, read := range []int{len(podList), 10} {
+		_, span := provider.Tracer("test").Start(context.TODO(), "list")
+		body := &listBody{ReadCloser: io.NopCloser(strings.NewReader(podList)), span: span}
+		if _, err := io.ReadAll(io.LimitReader(iotest.OneByteReader(body), int64(read))); err != nil {
+			t.Fatalf("Unexpect error: %v", err)
+		}
+		if read == len(podList) {
+			// reach EOF
+			_, _ = body.Read(make([]byte, 1))
+		}
+		if len(recorder.Ended()) != 0 {
+			t.Fatalf("Unexpect span ended before the body is closed")
+		}
+		_ = body.Close()
+
+		spans := recorder.Ended()
+		if len(spans) != 1 {
+			t.Fatalf("Unexpect spans: %d, expect: %d", len(spans), 1)
+		}
+		attrs := make(map[attribute.Key]attribute.Value)
+		for _, attr := range spans[0].Attributes() {
+			attrs[attr.Key] = attr.Value
+		}
+		if size := attrs[semconv.HTTPResponseBodySizeKey].AsInt64(); size != int64(read) {
+			t.Errorf("Unexpect body size: %d, expect: %d", size, read)
+		}
+		if _, counted := attrs[ItemCountKey]; counted != (read == len(podList)) {
+			t.Errorf("Unexpect item count of a body read by %d bytes: %v", read, attrs[ItemCountKey].Emit())
+		}
+		recorder = tracetest.NewSpanRecorder()
+		provider = sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	}
+}